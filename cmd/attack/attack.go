@@ -13,7 +13,19 @@
 
 package attack
 
-import "github.com/spf13/cobra"
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
 
 func NewAttackCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -27,7 +39,54 @@ func NewAttackCommand() *cobra.Command {
 		NewStressAttackCommand(),
 		NewDiskAttackCommand(),
 		NewHostAttackCommand(),
+		NewContainerAttackCommand(),
 	)
 
 	return cmd
 }
+
+func setScheduleFlags(cmd *cobra.Command, config *core.SchedulerConfig) {
+	cmd.PersistentFlags().StringVar(&config.Duration, "duration", "",
+		"recover the attack automatically after this duration, time units: s, m, h. "+
+			"Chaosd keeps running in the foreground until the attack is recovered")
+}
+
+// attackSuccessExit prints msg and exits. If the attack has a duration, it
+// waits until the attack is recovered automatically before exiting, and
+// recovers it at once if chaosd is interrupted.
+func attackSuccessExit(chaos *chaosd.Server, options core.AttackConfig, uid string, msg string) {
+	duration, _ := options.ScheduleDuration()
+	if duration == nil {
+		utils.NormalExit(msg)
+	}
+
+	fmt.Fprintf(os.Stdout, "%s, it will be recovered after %s\n", msg, duration)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		if err := chaos.RecoverAttack(uid); err != nil {
+			utils.ExitWithError(utils.ExitError, err)
+		}
+	}()
+
+	if err := chaos.WaitRecover(uid); err != nil {
+		utils.ExitWithError(utils.ExitError, err)
+	}
+
+	utils.NormalExit(fmt.Sprintf("Recover %s successfully", uid))
+}
+
+// signalContext returns a context which is cancelled once chaosd is interrupted or terminated,
+// the hidden subcommands running in the background stop their work with it.
+func signalContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		cancel()
+	}()
+	return ctx
+}
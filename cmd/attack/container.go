@@ -0,0 +1,98 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attack
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/chaos-mesh/chaosd/cmd/server"
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+func NewContainerAttackCommand() *cobra.Command {
+	options := core.NewContainerCommand()
+	dep := fx.Options(
+		server.Module,
+		fx.Provide(func() *core.ContainerCommand {
+			return options
+		}),
+	)
+
+	cmd := &cobra.Command{
+		Use:   "container <subcommand>",
+		Short: "Container attack related commands",
+	}
+	setScheduleFlags(cmd, &options.SchedulerConfig)
+	cmd.PersistentFlags().StringVar(&options.ContainerID, "container-id", "",
+		"the container to attack, such as docker://xxx, containerd://xxx or cri-o://xxx")
+
+	cmd.AddCommand(
+		NewContainerKillCommand(dep, options),
+		NewContainerPauseCommand(dep, options),
+		NewContainerRestartCommand(dep, options),
+	)
+
+	return cmd
+}
+
+func NewContainerKillCommand(dep fx.Option, options *core.ContainerCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill",
+		Short: "kill container with SIGKILL",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.ContainerKillAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(containerAttackF)).Run()
+		},
+	}
+}
+
+func NewContainerPauseCommand(dep fx.Option, options *core.ContainerCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause",
+		Short: "pause all processes in container, it is recovered by unpausing the container",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.ContainerPauseAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(containerAttackF)).Run()
+		},
+	}
+}
+
+func NewContainerRestartCommand(dep fx.Option, options *core.ContainerCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart",
+		Short: "restart container",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.ContainerRestartAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(containerAttackF)).Run()
+		},
+	}
+}
+
+func containerAttackF(chaos *chaosd.Server, options *core.ContainerCommand) {
+	if err := options.Validate(); err != nil {
+		utils.ExitWithError(utils.ExitBadArgs, err)
+	}
+
+	uid, err := chaos.ExecuteAttack(chaosd.ContainerAttack, options)
+	if err != nil {
+		utils.ExitWithError(utils.ExitError, err)
+	}
+
+	attackSuccessExit(chaos, options, uid, fmt.Sprintf("Attack container %s successfully, uid: %s", options.ContainerID, uid))
+}
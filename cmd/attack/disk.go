@@ -14,13 +14,17 @@
 package attack
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
 
 	"github.com/chaos-mesh/chaosd/cmd/server"
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/fusefault"
+	"github.com/chaos-mesh/chaosd/pkg/loadgen"
 	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
@@ -38,9 +42,15 @@ func NewDiskAttackCommand() *cobra.Command {
 		Use:   "disk <subcommand>",
 		Short: "disk attack related command",
 	}
+	setScheduleFlags(cmd, &options.SchedulerConfig)
 	cmd.AddCommand(
 		NewDiskPayloadCommand(dep, options),
 		NewDiskFillCommand(dep, options),
+		NewDiskFaultCommand(dep, options),
+		NewDiskInodeFillCommand(dep, options),
+		NewDiskFillWatcherCommand(),
+		NewDiskPayloadRunnerCommand(),
+		NewDiskFaultServerCommand(),
 	)
 	return cmd
 }
@@ -54,6 +64,7 @@ func NewDiskPayloadCommand(dep fx.Option, options *core.DiskOption) *cobra.Comma
 	cmd.AddCommand(
 		NewDiskWritePayloadCommand(dep, options),
 		NewDiskReadPayloadCommand(dep, options),
+		NewDiskSustainedPayloadCommand(dep, options),
 	)
 
 	return cmd
@@ -105,6 +116,34 @@ func NewDiskReadPayloadCommand(dep fx.Option, options *core.DiskOption) *cobra.C
 	return cmd
 }
 
+func NewDiskSustainedPayloadCommand(dep fx.Option, options *core.DiskOption) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sustained",
+		Short: "read and write a file at a target rate until the attack is recovered",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.DiskSustainedPayloadAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(processDiskAttack)).Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Size, "size", "s", "",
+		"'size' specifies the size of the file region to read and write, such as 1G | 512MB")
+	cmd.Flags().StringVarP(&options.Path, "path", "p", "",
+		"'path' specifies the file to read and write. "+
+			"If path not provided or is a directory, a temp file will be generated in it and removed when the attack is recovered. "+
+			"An existing file is only read, so read-percent must be 100")
+	cmd.Flags().IntVar(&options.ReadPercent, "read-percent", 0,
+		"'read-percent' specifies the percent of reads, the others are writes, only 0-100 is valid value")
+	cmd.Flags().StringVar(&options.BlockSize, "block-size", core.DefaultDiskPayloadBlockSize,
+		"'block-size' specifies the size of each read or write")
+	cmd.Flags().StringVar(&options.Rate, "rate", "",
+		"'rate' limits the throughput per second, such as 10MB, unlimited by default")
+	cmd.Flags().Int64Var(&options.IOPS, "iops", 0,
+		"'iops' limits the reads and writes per second, unlimited by default")
+	cmd.Flags().BoolVar(&options.Random, "random", false, "read and write the blocks in random order instead of sequentially")
+	return cmd
+}
+
 func NewDiskFillCommand(dep fx.Option, options *core.DiskOption) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "fill",
@@ -121,15 +160,146 @@ func NewDiskFillCommand(dep fx.Option, options *core.DiskOption) *cobra.Command
 			"M=1024*1024, , GB=1000*1000*1000, G=1024*1024*1024 BYTES"+
 			"example : 1M | 512kB")
 	cmd.Flags().StringVarP(&options.Path, "path", "p", "",
-		"'path' specifies the location to fill data in."+
-			"If path not provided, a temp file will be generated and deleted immediately after data filled in or allocated")
+		"'path' specifies the location to fill data in. "+
+			"If path not provided or is a directory, a temp file will be generated in it. "+
+			"The file created by chaosd is removed when the attack is recovered, an existing file is never removed")
 	cmd.Flags().StringVarP(&options.Percent, "percent", "c", "",
 		"'percent' how many percent data of disk will fill in the file path")
 	cmd.Flags().BoolVarP(&options.FillByFallocate, "fallocate", "f", true, "fill disk by fallocate instead of dd")
-	cmd.Flags().BoolVarP(&options.DestroyFile, "destroy", "d", false, "destroy file after filled in or allocated")
+	cmd.Flags().BoolVarP(&options.DestroyFile, "destroy", "d", false, "destroy file after filled in or allocated, the existing file is kept")
+	cmd.Flags().StringVar(&options.UntilUsed, "until-used", "",
+		"'until-used' fills the disk until its usage reaches the percent, such as 95%, the size is computed from the current free space")
+	cmd.Flags().StringVar(&options.LeaveFree, "leave-free", "",
+		"'leave-free' fills the disk until only the size is free, such as 500MB, the size is computed from the current free space")
+	cmd.Flags().BoolVar(&options.Watch, "watch", false,
+		"'watch' tops the file up in the background when other processes free space, "+
+			"so that the disk stays at the target of until-used or leave-free until the attack is recovered")
+	cmd.Flags().StringVar(&options.WatchInterval, "watch-interval", "",
+		"'watch-interval' is the interval to check the free space of the disk, default "+core.DefaultDiskWatchInterval)
+	return cmd
+}
+
+func NewDiskInodeFillCommand(dep fx.Option, options *core.DiskOption) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inode-fill",
+		Short: "fill the inodes of disk",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.DiskInodeFillAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(processDiskAttack), fx.NopLogger).Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Path, "path", "p", "",
+		"'path' specifies the directory on the disk to fill, the empty files are created in a new directory in it, "+
+			"which is removed when the attack is recovered. If path not provided, the current directory is used")
+	cmd.Flags().StringVar(&options.UntilUsed, "until-used", "",
+		"'until-used' creates empty files until the inode usage of the disk reaches the percent, such as 95%")
+	return cmd
+}
+
+func NewDiskFaultCommand(dep fx.Option, options *core.DiskOption) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fault",
+		Short: "inject latency and errors into the io of a directory",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.DiskFaultAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(processDiskAttack)).Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Path, "path", "p", "",
+		"'path' specifies the directory to inject faults, a FUSE passthrough file system is mounted over it "+
+			"until the attack is recovered")
+	cmd.Flags().StringVar(&options.MountPath, "mount-path", "",
+		"'mount-path' mounts the FUSE file system of path on the directory instead of over path, "+
+			"the faults are only injected into the io through mount-path")
+	cmd.Flags().StringVar(&options.Latency, "latency", "", "'latency' delays the faulted operations, such as 200ms")
+	cmd.Flags().StringVar(&options.Errno, "errno", "", "'errno' fails the faulted operations, such as EIO, ENOSPC or 5")
+	cmd.Flags().StringVar(&options.Percent, "percent", "",
+		"'percent' specifies the percent of the operations which are faulted, default 100")
+	cmd.Flags().StringSliceVar(&options.Methods, "methods", nil,
+		"'methods' specifies the faulted operations, all operations by default, support "+strings.Join(core.DiskFaultMethods, ", "))
 	return cmd
 }
 
+// NewDiskFillWatcherCommand creates the hidden command which keeps the disk at the target usage,
+// it runs in the background until the experiment is recovered.
+func NewDiskFillWatcherCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:    chaosd.DiskFillWatcherCommand + " <disk option>",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			options := core.NewDiskOption()
+			if err := json.Unmarshal([]byte(args[0]), options); err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
+
+			if err := chaosd.WatchDiskFill(signalContext(), options); err != nil {
+				utils.ExitWithError(utils.ExitError, err)
+			}
+		},
+	}
+}
+
+// NewDiskPayloadRunnerCommand creates the hidden command which runs the sustained payload,
+// it runs in the background until the experiment is recovered.
+func NewDiskPayloadRunnerCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:    chaosd.DiskPayloadRunnerCommand + " <disk option>",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			options := core.NewDiskOption()
+			if err := json.Unmarshal([]byte(args[0]), options); err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
+			ioOptions, err := chaosd.SustainedPayloadIOOptions(options)
+			if err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
+
+			if err := loadgen.RunIO(signalContext(), ioOptions); err != nil {
+				utils.ExitWithError(utils.ExitError, err)
+			}
+		},
+	}
+}
+
+// NewDiskFaultServerCommand creates the hidden command which serves the FUSE file system with faults,
+// it runs in the background until the experiment is recovered.
+func NewDiskFaultServerCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:    chaosd.DiskFaultServerCommand + " <disk option>",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			options := core.NewDiskOption()
+			if err := json.Unmarshal([]byte(args[0]), options); err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
+			faultOptions, err := chaosd.DiskFaultOptions(options)
+			if err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
+
+			ctx := signalContext()
+			server, err := fusefault.Mount(faultOptions)
+			if err != nil {
+				utils.ExitWithError(utils.ExitError, err)
+			}
+			<-ctx.Done()
+
+			// the files in use keep the file system busy, detach it instead
+			if err := server.Unmount(); err != nil {
+				if err := fusefault.ForceUnmount(faultOptions.MountPoint); err != nil {
+					utils.ExitWithError(utils.ExitError, err)
+				}
+			}
+		},
+	}
+}
+
 func processDiskAttack(options *core.DiskOption, chaos *chaosd.Server) {
 	if err := options.Validate(); err != nil {
 		utils.ExitWithError(utils.ExitBadArgs, err)
@@ -140,10 +310,16 @@ func processDiskAttack(options *core.DiskOption, chaos *chaosd.Server) {
 	}
 
 	if options.String() == core.DiskWritePayloadAction {
-		utils.NormalExit(fmt.Sprintf("Write file %s successfully, uid: %s", options.Path, uid))
+		attackSuccessExit(chaos, options, uid, fmt.Sprintf("Write file %s successfully, uid: %s", options.Path, uid))
 	} else if options.String() == core.DiskReadPayloadAction {
-		utils.NormalExit(fmt.Sprintf("Read file %s successfully, uid: %s", options.Path, uid))
+		attackSuccessExit(chaos, options, uid, fmt.Sprintf("Read file %s successfully, uid: %s", options.Path, uid))
+	} else if options.String() == core.DiskInodeFillAction {
+		attackSuccessExit(chaos, options, uid, fmt.Sprintf("Fill inodes in %s successfully, uid: %s", options.FillDir, uid))
+	} else if options.String() == core.DiskFaultAction {
+		attackSuccessExit(chaos, options, uid, fmt.Sprintf("Inject faults into %s successfully, uid: %s", options.FaultMountPoint(), uid))
+	} else if options.String() == core.DiskSustainedPayloadAction {
+		attackSuccessExit(chaos, options, uid, fmt.Sprintf("Start sustained payload on file %s successfully, uid: %s", options.Path, uid))
 	} else {
-		utils.NormalExit(fmt.Sprintf("Fill file %s successfully, uid: %s", options.Path, uid))
+		attackSuccessExit(chaos, options, uid, fmt.Sprintf("Fill file %s successfully, uid: %s", options.Path, uid))
 	}
 }
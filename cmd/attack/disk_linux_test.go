@@ -0,0 +1,144 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package attack
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/chaos-mesh/chaosd/cmd/server"
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+// mountTmpfs mounts a tmpfs on a temp dir, so that the usage of the disk only changes with the test.
+// The test is skipped if it's not permitted.
+func mountTmpfs(t *testing.T, data string) (string, func()) {
+	if os.Geteuid() != 0 {
+		t.Skip("mounting the tmpfs requires root")
+	}
+	dir, err := ioutil.TempDir("", "chaosd-disk")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if err := syscall.Mount("tmpfs", dir, "tmpfs", 0, data); err != nil {
+		os.RemoveAll(dir)
+		t.Skipf("mounting the tmpfs is not permitted: %v", err)
+	}
+	return dir, func() {
+		_ = syscall.Unmount(dir, syscall.MNT_DETACH)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestWatchDiskFill(t *testing.T) {
+	dir, cleanup := mountTmpfs(t, "size=64M")
+	defer cleanup()
+
+	other := filepath.Join(dir, "other")
+	if !assert.NoError(t, ioutil.WriteFile(other, make([]byte, 8<<20), 0644)) {
+		return
+	}
+	option := core.NewDiskOption()
+	option.Action = core.DiskFillAction
+	option.Path = filepath.Join(dir, "fill")
+	option.LeaveFree = "16M"
+	option.Watch = true
+	option.WatchInterval = "100ms"
+	option.FillByFallocate = true
+	if !assert.NoError(t, ioutil.WriteFile(option.Path, nil, 0644)) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- chaosd.WatchDiskFill(ctx, option)
+	}()
+	defer cancel()
+
+	// the file is topped up until 16M is free, and again when the other file frees space
+	leftFree := func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			avail, err := utils.GetDiskAvailSize(dir)
+			return err == nil && avail <= 17<<20 && avail >= 15<<20
+		}, 5*time.Second, 50*time.Millisecond)
+	}
+	leftFree(t)
+	stat, err := os.Stat(option.Path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, os.Remove(other))
+	leftFree(t)
+	topped, err := os.Stat(option.Path)
+	if assert.NoError(t, err) {
+		assert.InDelta(t, stat.Size()+8<<20, topped.Size(), 1<<20)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Error("the watcher doesn't stop after the context is done")
+	}
+}
+
+func TestServer_DiskInodeFillRecover(t *testing.T) {
+	dir, cleanup := mountTmpfs(t, "size=16M,nr_inodes=5000")
+	defer cleanup()
+
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			option := core.NewDiskOption()
+			option.Action = core.DiskInodeFillAction
+			option.Path = dir
+			option.UntilUsed = "90%"
+			uid, err := s.ExecuteAttack(chaosd.DiskAttack, option)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, dir, filepath.Dir(option.FillDir))
+
+			total, free, err := utils.GetDiskInodes(dir)
+			if assert.NoError(t, err) {
+				assert.Equal(t, total/10, free)
+			}
+
+			assert.NoError(t, s.RecoverAttack(uid))
+			_, err = os.Stat(option.FillDir)
+			assert.True(t, os.IsNotExist(err))
+			_, free, err = utils.GetDiskInodes(dir)
+			if assert.NoError(t, err) {
+				assert.InDelta(t, total, free, 1)
+			}
+		}),
+	)
+}
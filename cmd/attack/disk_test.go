@@ -14,8 +14,13 @@
 package attack
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/fx"
@@ -23,6 +28,7 @@ import (
 
 	"github.com/chaos-mesh/chaosd/cmd/server"
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/loadgen"
 	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
@@ -102,6 +108,196 @@ func TestServer_DiskFill(t *testing.T) {
 	)
 }
 
+func TestServer_DiskFillRecover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaosd-disk")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	existing := filepath.Join(dir, "existing")
+	if !assert.NoError(t, ioutil.WriteFile(existing, nil, 0644)) {
+		return
+	}
+
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			for _, tt := range []struct {
+				name    string
+				path    string
+				removed bool
+			}{
+				{name: "new file", path: filepath.Join(dir, "new"), removed: true},
+				{name: "directory", path: dir, removed: true},
+				{name: "existing file", path: existing, removed: false},
+			} {
+				t.Run(tt.name, func(t *testing.T) {
+					option := core.NewDiskOption()
+					option.Action = core.DiskFillAction
+					option.Size = "1M"
+					option.Path = tt.path
+					option.PayloadProcessNum = 1
+					uid, err := s.ExecuteAttack(chaosd.DiskAttack, option)
+					if !assert.NoError(t, err) {
+						return
+					}
+					if tt.removed {
+						assert.Equal(t, option.Path, option.FillFile)
+						assert.Equal(t, dir, filepath.Dir(option.FillFile))
+					} else {
+						assert.Empty(t, option.FillFile)
+					}
+					stat, err := os.Stat(option.Path)
+					if assert.NoError(t, err) {
+						assert.Equal(t, int64(1<<20), stat.Size())
+					}
+
+					assert.NoError(t, s.RecoverAttack(uid))
+					_, err = os.Stat(option.Path)
+					assert.Equal(t, tt.removed, os.IsNotExist(err))
+				})
+			}
+		}),
+	)
+}
+
+func TestServer_DiskFillDestroy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaosd-disk")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	existing := filepath.Join(dir, "existing")
+	if !assert.NoError(t, ioutil.WriteFile(existing, nil, 0644)) {
+		return
+	}
+
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			for _, tt := range []struct {
+				name    string
+				path    string
+				removed bool
+			}{
+				{name: "new file", path: filepath.Join(dir, "new"), removed: true},
+				{name: "existing file", path: existing, removed: false},
+			} {
+				t.Run(tt.name, func(t *testing.T) {
+					option := core.NewDiskOption()
+					option.Action = core.DiskFillAction
+					option.Size = "1M"
+					option.Path = tt.path
+					option.DestroyFile = true
+					option.PayloadProcessNum = 1
+					uid, err := s.ExecuteAttack(chaosd.DiskAttack, option)
+					if !assert.NoError(t, err) {
+						return
+					}
+					_, err = os.Stat(tt.path)
+					assert.Equal(t, tt.removed, os.IsNotExist(err))
+					assert.NoError(t, s.RecoverAttack(uid))
+				})
+			}
+		}),
+	)
+}
+
+func TestServer_DiskFillRestoreSchedule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaosd-disk")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			option := core.NewDiskOption()
+			option.Action = core.DiskFillAction
+			option.Size = "1M"
+			option.Path = filepath.Join(dir, "fill")
+			option.PayloadProcessNum = 1
+			option.Schedule = "@every 1s"
+			uid, err := s.ExecuteAttack(chaosd.DiskAttack, option)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() {
+				assert.NoError(t, s.RecoverAttack(uid))
+			}()
+
+			// the fill file of the run is saved in the recover data
+			time.Sleep(1500 * time.Millisecond)
+			exps, err := s.Search(&core.SearchCommand{UID: uid})
+			if !assert.NoError(t, err) || !assert.Len(t, exps, 1) {
+				return
+			}
+			assert.Contains(t, exps[0].RecoverCommand, `"fill_file"`)
+
+			// restart the schedule as chaosd does when it starts
+			assert.NoError(t, s.Cron.Remove(exps[0].ID))
+			assert.NoError(t, chaosd.Restore(s))
+			assert.True(t, s.Cron.Scheduled(exps[0].ID))
+			exps, err = s.Search(&core.SearchCommand{UID: uid})
+			if assert.NoError(t, err) && assert.Len(t, exps, 1) {
+				assert.Equal(t, core.Scheduled, exps[0].Status)
+				assert.Empty(t, exps[0].Message)
+			}
+		}),
+	)
+}
+
+func TestRunIO(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaosd-disk")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "payload")
+	if !assert.NoError(t, ioutil.WriteFile(path, nil, 0644)) {
+		return
+	}
+	if !assert.NoError(t, loadgen.PrepareIOFile(path, 1<<20)) {
+		return
+	}
+
+	// the blocks are written in order at 20 iops, so about 10 blocks are written in 500ms
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- loadgen.RunIO(ctx, loadgen.IOOptions{
+			Path:      path,
+			Size:      1 << 20,
+			BlockSize: 4096,
+			IOPS:      20,
+		})
+	}()
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the io load doesn't stop after the context is done")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	written := 0
+	for offset := 0; offset < len(data); offset += 4096 {
+		if !bytes.Equal(data[offset:offset+4096], make([]byte, 4096)) {
+			written++
+		}
+	}
+	assert.InDelta(t, 10, written, 3)
+}
+
 func TestServer_DiskPayload(t *testing.T) {
 	fxtest.New(
 		t,
@@ -25,6 +25,11 @@ import (
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
+// directionHelp is the help of the direction flag of the tc attacks. The flags of the subcommands share
+// the options, so the default value is completed by the action instead of the flag.
+const directionHelp = "the direction of the traffic to impact, supported: egress, ingress, default egress. " +
+	"The ingress traffic is redirected to an IFB device, and can't be filtered by IP addresses, hostnames, protocol or ports"
+
 func NewNetworkAttackCommand() *cobra.Command {
 	options := core.NewNetworkCommand()
 	dep := fx.Options(
@@ -38,6 +43,9 @@ func NewNetworkAttackCommand() *cobra.Command {
 		Use:   "network <subcommand>",
 		Short: "Network attack related commands",
 	}
+	setScheduleFlags(cmd, &options.SchedulerConfig)
+	cmd.PersistentFlags().StringVar(&options.ContainerID, "container-id", "",
+		"apply the attack in the network namespace of this container, such as docker://xxx, containerd://xxx or cri-o://xxx")
 
 	cmd.AddCommand(
 		NewNetworkDelayCommand(dep, options),
@@ -45,6 +53,8 @@ func NewNetworkAttackCommand() *cobra.Command {
 		NewNetworkCorruptCommand(dep, options),
 		NetworkDuplicateCommand(dep, options),
 		NetworkDNSCommand(dep, options),
+		NewNetworkPartitionCommand(dep, options),
+		NewNetworkBandwidthCommand(dep, options),
 	)
 
 	return cmd
@@ -67,7 +77,14 @@ func NewNetworkDelayCommand(dep fx.Option, options *core.NetworkCommand) *cobra.
 	cmd.Flags().StringVarP(&options.Jitter, "jitter", "j", "",
 		"jitter time, time units: ns, us (or µs), ms, s, m, h.")
 	cmd.Flags().StringVarP(&options.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
+	cmd.Flags().StringVar(&options.Reorder, "reorder", "",
+		"percentage of packets to send immediately (10 is 10%), the other packets are delayed")
+	cmd.Flags().StringVar(&options.ReorderCorrelation, "reorder-correlation", "",
+		"reorder correlation is percentage (10 is 10%)")
+	cmd.Flags().IntVar(&options.Gap, "gap", 0,
+		"reorder every gap-th packet, the packets in the gap are delayed")
 	cmd.Flags().StringVarP(&options.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&options.Direction, "direction", "", directionHelp)
 	cmd.Flags().StringVarP(&options.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -97,6 +114,7 @@ func NewNetworkLossCommand(dep fx.Option, options *core.NetworkCommand) *cobra.C
 	cmd.Flags().StringVar(&options.Percent, "percent", "1", "percentage of packets to drop (10 is 10%)")
 	cmd.Flags().StringVarP(&options.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
 	cmd.Flags().StringVarP(&options.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&options.Direction, "direction", "", directionHelp)
 	cmd.Flags().StringVarP(&options.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -126,6 +144,7 @@ func NewNetworkCorruptCommand(dep fx.Option, options *core.NetworkCommand) *cobr
 	cmd.Flags().StringVar(&options.Percent, "percent", "1", "percentage of packets to corrupt (10 is 10%)")
 	cmd.Flags().StringVarP(&options.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
 	cmd.Flags().StringVarP(&options.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&options.Direction, "direction", "", directionHelp)
 	cmd.Flags().StringVarP(&options.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -155,6 +174,7 @@ func NetworkDuplicateCommand(dep fx.Option, options *core.NetworkCommand) *cobra
 	cmd.Flags().StringVar(&options.Percent, "percent", "1", "percentage of packets to corrupt (10 is 10%)")
 	cmd.Flags().StringVarP(&options.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
 	cmd.Flags().StringVarP(&options.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&options.Direction, "direction", "", directionHelp)
 	cmd.Flags().StringVarP(&options.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -189,6 +209,72 @@ func NetworkDNSCommand(dep fx.Option, options *core.NetworkCommand) *cobra.Comma
 	return cmd
 }
 
+func NewNetworkPartitionCommand(dep fx.Option, options *core.NetworkCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "partition",
+		Short: "block the traffic between this host and the specified IP addresses or hostnames",
+
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.NetworkPartitionAction
+			options.CompleteDefaults()
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(commonNetworkAttackFunc)).Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.IPAddress, "ip", "i", "", "block the traffic with these IP addresses")
+	cmd.Flags().StringVarP(&options.Hostname, "hostname", "H", "", "block the traffic with these hostnames")
+	cmd.Flags().StringVar(&options.Direction, "direction", "",
+		"the direction of the traffic to block, supported: to, from, both, default both")
+	cmd.Flags().StringVarP(&options.EgressPort, "egress-port", "e", "",
+		"only block the traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&options.SourcePort, "source-port", "s", "",
+		"only block the traffic from these source ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&options.IPProtocol, "protocol", "p", "",
+		"only block the traffic using this IP protocol, supported: tcp, udp, icmp, all")
+
+	return cmd
+}
+
+func NewNetworkBandwidthCommand(dep fx.Option, options *core.NetworkCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bandwidth",
+		Short: "limit network bandwidth",
+
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.NetworkBandwidthAction
+			options.CompleteDefaults()
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(commonNetworkAttackFunc)).Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Rate, "rate", "r", "",
+		"the speed knob, allows bps, kbps, mbps, gbps, tbps unit. bps means bytes per second")
+	cmd.Flags().Uint32VarP(&options.Limit, "limit", "l", 0,
+		"the number of bytes that can be queued waiting for tokens to become available")
+	cmd.Flags().Uint32VarP(&options.Buffer, "buffer", "b", 0,
+		"the maximum amount of bytes that tokens can be available for instantaneously")
+	cmd.Flags().Uint64Var(&options.Peakrate, "peakrate", 0,
+		"the maximum depletion rate of the bucket, it can only be used in conjunction with --minburst")
+	cmd.Flags().Uint32Var(&options.Minburst, "minburst", 0,
+		"the size of the peakrate bucket, it can only be used in conjunction with --peakrate")
+	cmd.Flags().StringVarP(&options.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&options.Direction, "direction", "", directionHelp)
+	cmd.Flags().StringVarP(&options.EgressPort, "egress-port", "e", "",
+		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&options.SourcePort, "source-port", "s", "",
+		"only impact egress traffic from these source ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&options.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
+	cmd.Flags().StringVarP(&options.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVarP(&options.IPProtocol, "protocol", "p", "",
+		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+
+	return cmd
+}
+
 func commonNetworkAttackFunc(options *core.NetworkCommand, chaos *chaosd.Server) {
 	if err := options.Validate(); err != nil {
 		utils.ExitWithError(utils.ExitBadArgs, err)
@@ -199,5 +285,5 @@ func commonNetworkAttackFunc(options *core.NetworkCommand, chaos *chaosd.Server)
 		utils.ExitWithError(utils.ExitError, err)
 	}
 
-	utils.NormalExit(fmt.Sprintf("Attack network successfully, uid: %s", uid))
+	attackSuccessExit(chaos, options, uid, fmt.Sprintf("Attack network successfully, uid: %s", uid))
 }
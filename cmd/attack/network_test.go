@@ -0,0 +1,148 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/chaos-mesh/chaosd/cmd/server"
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+)
+
+// fakeIptables keeps the rules of the chains in a file, and records its calls.
+const fakeIptables = `#!/bin/sh
+dir=$(dirname "$0")
+state="$dir/iptables.rules"
+touch "$state"
+echo "iptables $*" >> "$dir/calls"
+[ "$1" = "-w" ] && shift
+case "$1" in
+-N) grep -q -- "^-N $2\$" "$state" && { echo "iptables: Chain already exists." >&2; exit 1; }
+	echo "-N $2" >> "$state" ;;
+-S) cat "$state" ;;
+-A) echo "$*" >> "$state" ;;
+-D) rule=$(echo "$*" | sed 's/^-D /-A /')
+	grep -vxF -- "$rule" "$state" > "$state.tmp"; mv "$state.tmp" "$state" ;;
+-F) grep -q -- "^-N $2\$" "$state" || { echo "iptables: No chain/target/match by that name." >&2; exit 1; }
+	grep -v -- "^-A $2 " "$state" > "$state.tmp"; mv "$state.tmp" "$state" ;;
+-X) grep -q -- "-j $2\( \|\$\)" "$state" && { echo "iptables: Too many links." >&2; exit 1; }
+	grep -q -- "^-N $2\$" "$state" || { echo "iptables: No chain/target/match by that name." >&2; exit 1; }
+	grep -vx -- "-N $2" "$state" > "$state.tmp"; mv "$state.tmp" "$state" ;;
+esac
+exit 0
+`
+
+// fakeIpset refuses to destroy the ipset used by an iptables rule like the kernel, or by another
+// component if the busy file exists, and records its calls.
+const fakeIpset = `#!/bin/sh
+dir=$(dirname "$0")
+echo "ipset $*" >> "$dir/calls"
+if [ "$1" = "destroy" ] && { [ -e "$dir/busy" ] || grep -q -- "--match-set $2 " "$dir/iptables.rules" 2>/dev/null; }; then
+	echo "ipset v7.1: Set cannot be destroyed: it is in use by a kernel component" >&2
+	exit 1
+fi
+exit 0
+`
+
+func TestServer_NetworkPartitionRecover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaosd-network")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	for name, script := range map[string]string{"iptables": fakeIptables, "ipset": fakeIpset} {
+		if !assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755)) {
+			return
+		}
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			options := core.NewNetworkCommand()
+			options.Action = core.NetworkPartitionAction
+			options.IPAddress = "1.2.3.4"
+			options.CompleteDefaults()
+			uid, err := s.ExecuteAttack(chaosd.NetworkAttack, options)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			rules, err := ioutil.ReadFile(filepath.Join(dir, "iptables.rules"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Contains(t, string(rules), "-N chaos-in-"+uid[:16])
+			assert.Contains(t, string(rules), "-N chaos-out-"+uid[:16])
+
+			assert.NoError(t, s.RecoverAttack(uid))
+			rules, err = ioutil.ReadFile(filepath.Join(dir, "iptables.rules"))
+			if assert.NoError(t, err) {
+				assert.NotContains(t, string(rules), "chaos-in-")
+				assert.NotContains(t, string(rules), "chaos-out-")
+			}
+			// the ipset is destroyed once the chains using it are deleted
+			deleted := callIndex(t, dir, "iptables -w -X chaos-out-"+uid[:16])
+			assert.NotEqual(t, -1, deleted)
+			assert.Greater(t, callIndex(t, dir, "ipset destroy "+chaosd.IPSetPrefix+uid[:16]), deleted)
+
+			// the recovery can be retried if the ipset can't be destroyed
+			options = core.NewNetworkCommand()
+			options.Action = core.NetworkPartitionAction
+			options.IPAddress = "1.2.3.4"
+			options.CompleteDefaults()
+			uid, err = s.ExecuteAttack(chaosd.NetworkAttack, options)
+			if !assert.NoError(t, err) {
+				return
+			}
+			busy := filepath.Join(dir, "busy")
+			if !assert.NoError(t, ioutil.WriteFile(busy, nil, 0644)) {
+				return
+			}
+			assert.Error(t, s.RecoverAttack(uid))
+			failed := callIndex(t, dir, "ipset destroy "+chaosd.IPSetPrefix+uid[:16])
+			assert.NotEqual(t, -1, failed)
+			assert.NoError(t, os.Remove(busy))
+			assert.NoError(t, s.RecoverAttack(uid))
+			assert.Greater(t, callIndex(t, dir, "ipset destroy "+chaosd.IPSetPrefix+uid[:16]), failed)
+		}),
+	)
+}
+
+// callIndex returns the index of the last call of the fake commands, or -1 if it's not called.
+func callIndex(t *testing.T, dir string, call string) int {
+	calls, err := ioutil.ReadFile(filepath.Join(dir, "calls"))
+	assert.NoError(t, err)
+
+	index := -1
+	for i, line := range strings.Split(string(calls), "\n") {
+		if line == call {
+			index = i
+		}
+	}
+	return index
+}
@@ -15,6 +15,8 @@ package attack
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
 
 	"syscall"
 
@@ -40,27 +42,60 @@ func NewProcessAttackCommand() *cobra.Command {
 		Use:   "process <subcommand>",
 		Short: "Process attack related commands",
 	}
+	setScheduleFlags(cmd, &options.SchedulerConfig)
 
 	cmd.AddCommand(
 		NewProcessKillCommand(dep, options),
 		NewProcessStopCommand(dep, options),
+		NewProcessFlapCommand(dep, options),
 	)
 
 	return cmd
 }
 
 func NewProcessKillCommand(dep fx.Option, options *core.ProcessCommand) *cobra.Command {
+	var signal string
 	cmd := &cobra.Command{
 		Use:   "kill",
 		Short: "kill process, default signal 9",
 		Run: func(*cobra.Command, []string) {
+			var err error
+			if options.Signal, err = core.ParseSignal(signal); err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
 			options.Action = core.ProcessKillAction
 			utils.FxNewAppWithoutLog(dep, fx.Invoke(processAttackF)).Run()
 		},
 	}
 
-	cmd.Flags().StringVarP(&options.Process, "process", "p", "", "The process name or the process ID")
-	cmd.Flags().IntVarP(&options.Signal, "signal", "s", 9, "The signal number to send")
+	setProcessSelectorFlags(cmd, options)
+	cmd.Flags().StringVarP(&signal, "signal", "s", "SIGKILL", "The signal to send, by its number or name, "+
+		"such as 15, SIGTERM or TERM. Only SIGSTOP can be recovered")
+
+	return cmd
+}
+
+func NewProcessFlapCommand(dep fx.Option, options *core.ProcessCommand) *cobra.Command {
+	var signal string
+	cmd := &cobra.Command{
+		Use:   "flap",
+		Short: "kill process repeatedly, and measure how long it takes to be restarted every time",
+		Run: func(*cobra.Command, []string) {
+			var err error
+			if options.Signal, err = core.ParseSignal(signal); err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
+			options.Action = core.ProcessFlapAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(processAttackF)).Run()
+		},
+	}
+
+	setProcessSelectorFlags(cmd, options)
+	cmd.Flags().StringVarP(&signal, "signal", "s", "SIGKILL", "The signal to send, by its number or name, "+
+		"such as 15, SIGTERM or TERM")
+	cmd.Flags().IntVar(&options.Times, "times", 0, "The count of the kills, 0 means killing until the duration runs out")
+	cmd.Flags().StringVar(&options.RestartTimeout, "restart-timeout", core.DefaultRestartTimeout.String(),
+		"The max time to wait for the killed process to be restarted")
 
 	return cmd
 }
@@ -76,11 +111,22 @@ func NewProcessStopCommand(dep fx.Option, options *core.ProcessCommand) *cobra.C
 		},
 	}
 
-	cmd.Flags().StringVarP(&options.Process, "process", "p", "", "The process name or the process ID")
+	setProcessSelectorFlags(cmd, options)
 
 	return cmd
 }
 
+func setProcessSelectorFlags(cmd *cobra.Command, options *core.ProcessCommand) {
+	cmd.Flags().StringVarP(&options.Process, "process", "p", "", "The process name or the process ID")
+	cmd.Flags().BoolVar(&options.Regex, "regex", false, "Match the process name with --process as a regular expression")
+	cmd.Flags().BoolVarP(&options.FullCmdline, "full", "f", false, "Match --process against the full command line instead of the process name")
+	cmd.Flags().StringVarP(&options.User, "user", "u", "", "Only match the processes owned by the user name or UID")
+	cmd.Flags().BoolVarP(&options.Newest, "newest", "n", false, "Only select the most recently started matched process")
+	cmd.Flags().BoolVarP(&options.Oldest, "oldest", "o", false, "Only select the least recently started matched process")
+	cmd.Flags().IntVar(&options.MaxCount, "max-count", 0, "The max count of the matched processes, 0 means no limit")
+	cmd.Flags().BoolVar(&options.KillChildren, "kill-children", false, "Send the signal to the whole process trees of the matched processes")
+}
+
 func processAttackF(options *core.ProcessCommand, chaos *chaosd.Server) {
 	if err := options.Validate(); err != nil {
 		utils.ExitWithError(utils.ExitBadArgs, err)
@@ -91,5 +137,33 @@ func processAttackF(options *core.ProcessCommand, chaos *chaosd.Server) {
 		utils.ExitWithError(utils.ExitError, err)
 	}
 
-	utils.NormalExit(fmt.Sprintf("Attack process %s successfully, uid: %s", options.Process, uid))
+	msg := fmt.Sprintf("Attack process %s successfully, uid: %s", options.Process, uid)
+	if duration, _ := options.ScheduleDuration(); options.Action == core.ProcessFlapAction && duration == nil {
+		flapSuccessExit(chaos, uid, msg)
+	}
+	attackSuccessExit(chaos, options, uid, msg)
+}
+
+// flapSuccessExit prints msg and exits once the flap is ended, since the processes are killed
+// by this chaosd process. The flap is recovered at once if chaosd is interrupted.
+func flapSuccessExit(chaos *chaosd.Server, uid string, msg string) {
+	fmt.Fprintf(os.Stdout, "%s, it will be ended after the kills\n", msg)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		chaos.WaitFlap(uid)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		utils.NormalExit(fmt.Sprintf("Flap %s is ended", uid))
+	case <-sig:
+		if err := chaos.RecoverAttack(uid); err != nil {
+			utils.ExitWithError(utils.ExitError, err)
+		}
+		utils.NormalExit(fmt.Sprintf("Recover %s successfully", uid))
+	}
 }
@@ -0,0 +1,296 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attack
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/chaos-mesh/chaosd/cmd/server"
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+)
+
+type processTest struct {
+	name string
+	// args are the arguments of the sleep processes started before the attack
+	args    []string
+	option  *core.ProcessCommand
+	wantIdx []int
+}
+
+func newProcessSelectorOption(pattern string) *core.ProcessCommand {
+	options := core.NewProcessCommand()
+	options.Action = core.ProcessKillAction
+	options.Signal = int(syscall.SIGKILL)
+	options.Process = pattern
+	options.Regex = true
+	options.FullCmdline = true
+	return options
+}
+
+func TestServer_ProcessSelector(t *testing.T) {
+	newest := newProcessSelectorOption("^sleep 100[1-3]$")
+	newest.Newest = true
+	oldest := newProcessSelectorOption("^sleep 200[1-3]$")
+	oldest.Oldest = true
+	maxCount := newProcessSelectorOption("^sleep 300[1-3]$")
+	maxCount.MaxCount = 2
+	exact := newProcessSelectorOption("sleep 4002")
+	exact.Regex = false
+
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Provide(func() []processTest {
+			return []processTest{
+				{name: "newest", args: []string{"1001", "1002", "1003"}, option: newest, wantIdx: []int{2}},
+				{name: "oldest", args: []string{"2001", "2002", "2003"}, option: oldest, wantIdx: []int{0}},
+				{name: "max-count", args: []string{"3001", "3002", "3003"}, option: maxCount, wantIdx: []int{0, 1}},
+				{name: "exact", args: []string{"4001", "4002"}, option: exact, wantIdx: []int{1}},
+			}
+		}),
+		fx.Invoke(func(s *chaosd.Server, tests []processTest) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					var cmds []*exec.Cmd
+					for _, arg := range tt.args {
+						cmd := exec.Command("sleep", arg)
+						if !assert.NoError(t, cmd.Start()) {
+							return
+						}
+						cmds = append(cmds, cmd)
+						// the start time of the processes is counted in clock ticks
+						time.Sleep(50 * time.Millisecond)
+					}
+					defer func() {
+						for _, cmd := range cmds {
+							_ = cmd.Process.Kill()
+							_ = cmd.Wait()
+						}
+					}()
+
+					_, err := s.ExecuteAttack(chaosd.ProcessAttack, tt.option)
+					assert.NoError(t, err)
+
+					var want []int
+					for _, idx := range tt.wantIdx {
+						want = append(want, cmds[idx].Process.Pid)
+					}
+					assert.Equal(t, want, tt.option.PIDs, fmt.Sprintf("processes of %v", tt.args))
+				})
+			}
+		}),
+	)
+}
+
+func TestServer_ProcessKillChildren(t *testing.T) {
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			cmd := exec.Command("sh", "-c", "sleep 5001 & sleep 5002 & wait")
+			if !assert.NoError(t, cmd.Start()) {
+				return
+			}
+			defer func() {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+			}()
+			time.Sleep(100 * time.Millisecond)
+
+			options := core.NewProcessCommand()
+			options.Action = core.ProcessStopAction
+			options.Signal = int(syscall.SIGSTOP)
+			options.Process = strconv.Itoa(cmd.Process.Pid)
+			options.KillChildren = true
+			uid, err := s.ExecuteAttack(chaosd.ProcessAttack, options)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() {
+				for _, pid := range options.PIDs {
+					_ = syscall.Kill(pid, syscall.SIGKILL)
+				}
+			}()
+
+			assert.Len(t, options.PIDs, 3)
+			assert.Equal(t, cmd.Process.Pid, options.PIDs[0])
+			for _, pid := range options.PIDs {
+				assert.Equal(t, "T", processStatus(t, pid))
+			}
+
+			assert.NoError(t, s.RecoverAttack(uid))
+			for _, pid := range options.PIDs {
+				assert.NotEqual(t, "T", processStatus(t, pid))
+			}
+		}),
+	)
+}
+
+func TestServer_ProcessStopRecoverExited(t *testing.T) {
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			var cmds []*exec.Cmd
+			for _, arg := range []string{"5003", "5004"} {
+				cmd := exec.Command("sleep", arg)
+				if !assert.NoError(t, cmd.Start()) {
+					return
+				}
+				cmds = append(cmds, cmd)
+				time.Sleep(50 * time.Millisecond)
+			}
+			defer func() {
+				for _, cmd := range cmds {
+					_ = cmd.Process.Kill()
+					_ = cmd.Wait()
+				}
+			}()
+
+			options := core.NewProcessCommand()
+			options.Action = core.ProcessStopAction
+			options.Signal = int(syscall.SIGSTOP)
+			options.Process = "^sleep 500[34]$"
+			options.Regex = true
+			options.FullCmdline = true
+			uid, err := s.ExecuteAttack(chaosd.ProcessAttack, options)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, []int{cmds[0].Process.Pid, cmds[1].Process.Pid}, options.PIDs)
+			assert.Len(t, options.StartTimes, 2)
+
+			// the process resumed first exits before the recovery, the other one is still resumed
+			assert.NoError(t, cmds[1].Process.Kill())
+			_ = cmds[1].Wait()
+			assert.NoError(t, s.RecoverAttack(uid))
+			assert.NotEqual(t, "T", processStatus(t, cmds[0].Process.Pid))
+		}),
+	)
+}
+
+func processStatus(t *testing.T, pid int) string {
+	p, err := process.NewProcess(int32(pid))
+	assert.NoError(t, err)
+	status, err := p.Status()
+	assert.NoError(t, err)
+	return status
+}
+
+func TestServer_ProcessFlap(t *testing.T) {
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			// the shell restarts the killed sleep process like a supervisor
+			cmd := exec.Command("sh", "-c", "while true; do sleep 6001; done")
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			if !assert.NoError(t, cmd.Start()) {
+				return
+			}
+			defer func() {
+				_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+				_ = cmd.Wait()
+			}()
+			time.Sleep(100 * time.Millisecond)
+
+			options := core.NewProcessCommand()
+			options.Action = core.ProcessFlapAction
+			options.Signal = int(syscall.SIGKILL)
+			options.Process = "sleep 6001"
+			options.FullCmdline = true
+			options.Times = 2
+			options.RestartTimeout = "5s"
+			uid, err := s.ExecuteAttack(chaosd.ProcessAttack, options)
+			if !assert.NoError(t, err) {
+				return
+			}
+			exp, err := s.Search(&core.SearchCommand{UID: uid})
+			if assert.NoError(t, err) && assert.Len(t, exp, 1) {
+				assert.Equal(t, core.Success, exp[0].Status)
+			}
+
+			s.WaitFlap(uid)
+			runs, err := s.SearchRuns(uid)
+			assert.NoError(t, err)
+			assert.Len(t, runs, 2)
+			for _, run := range runs {
+				assert.Equal(t, core.Success, run.Status)
+				assert.True(t, run.FinishedAt.After(run.StartAt))
+			}
+		}),
+	)
+}
+
+func TestServer_ProcessFlapRecover(t *testing.T) {
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			cmd := exec.Command("sh", "-c", "while true; do sleep 6002; done")
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			if !assert.NoError(t, cmd.Start()) {
+				return
+			}
+			defer func() {
+				_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+				_ = cmd.Wait()
+			}()
+			time.Sleep(100 * time.Millisecond)
+
+			// the flap never ends by itself in the test, it is stopped by the recovery
+			options := core.NewProcessCommand()
+			options.Action = core.ProcessFlapAction
+			options.Signal = int(syscall.SIGKILL)
+			options.Process = "sleep 6002"
+			options.FullCmdline = true
+			options.Duration = "1h"
+			uid, err := s.ExecuteAttack(chaosd.ProcessAttack, options)
+			if !assert.NoError(t, err) {
+				return
+			}
+			time.Sleep(300 * time.Millisecond)
+
+			assert.NoError(t, s.RecoverAttack(uid))
+			runs, err := s.SearchRuns(uid)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, runs)
+			for _, run := range runs {
+				assert.NotEqual(t, core.Running, run.Status)
+			}
+
+			exp, err := s.Search(&core.SearchCommand{UID: uid})
+			if assert.NoError(t, err) && assert.Len(t, exp, 1) {
+				assert.Equal(t, core.Destroyed, exp[0].Status)
+			}
+
+			// no more kills after the recovery
+			time.Sleep(300 * time.Millisecond)
+			after, err := s.SearchRuns(uid)
+			assert.NoError(t, err)
+			assert.Len(t, after, len(runs))
+		}),
+	)
+}
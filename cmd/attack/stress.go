@@ -15,12 +15,14 @@ package attack
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
 
 	"github.com/chaos-mesh/chaosd/cmd/server"
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/loadgen"
 	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
@@ -38,26 +40,39 @@ func NewStressAttackCommand() *cobra.Command {
 		Use:   "stress <subcommand>",
 		Short: "Stress attack related commands",
 	}
+	setScheduleFlags(cmd, &options.SchedulerConfig)
+	cmd.PersistentFlags().StringVar(&options.ContainerID, "container-id", "", "the stress-ng process is confined to the cgroups of the container, such as docker://xxx, containerd://xxx or cri-o://xxx")
+	cmd.PersistentFlags().StringVar(&options.CGroup, "cgroup", "", "the stress-ng process is confined to the cgroup path, such as /kubepods/pod1/xxx")
 
 	cmd.AddCommand(
 		NewStressCPUCommand(dep, options),
 		NewStressMemCommand(dep, options),
+		newStressWorkersCommand(dep, options, core.StressIOAction, "continuously call sync() to commit buffer cache to disk"),
+		NewStressHDDCommand(dep, options),
+		newStressWorkersCommand(dep, options, core.StressForkAction, "continuously fork child processes that exit immediately"),
+		newStressWorkersCommand(dep, options, core.StressSwitchAction, "continuously force context switching between processes"),
+		newStressWorkersCommand(dep, options, core.StressCacheAction, "continuously thrash the CPU cache"),
+		NewStressCustomCommand(dep, options),
+		NewStressLoadProfileCommand(),
 	)
 
 	return cmd
 }
 
 func NewStressCPUCommand(dep fx.Option, options *core.StressCommand) *cobra.Command {
+	var profileFile string
 	cmd := &cobra.Command{
 		Use:   "cpu [options]",
 		Short: "continuously stress CPU out",
 		Run: func(*cobra.Command, []string) {
 			options.Action = core.StressCPUAction
+			readProfileFile(options, profileFile)
 			utils.FxNewAppWithoutLog(dep, fx.Invoke(stressAttackF)).Run()
 		},
 	}
 
 	cmd.Flags().IntVarP(&options.Load, "load", "l", 10, "Load specifies P percent loading per CPU worker. 0 is effectively a sleep (no load) and 100 is full loading.")
+	setProfileFlags(cmd, options, &profileFile)
 	cmd.Flags().IntVarP(&options.Workers, "workers", "w", 1, "Workers specifies N workers to apply the stressor.")
 	cmd.Flags().StringSliceVarP(&options.Options, "options", "o", []string{}, "extend stress-ng options.")
 
@@ -65,11 +80,13 @@ func NewStressCPUCommand(dep fx.Option, options *core.StressCommand) *cobra.Comm
 }
 
 func NewStressMemCommand(dep fx.Option, options *core.StressCommand) *cobra.Command {
+	var profileFile string
 	cmd := &cobra.Command{
 		Use:   "mem [options]",
 		Short: "continuously stress virtual memory out",
 		Run: func(*cobra.Command, []string) {
 			options.Action = core.StressMemAction
+			readProfileFile(options, profileFile)
 			utils.FxNewAppWithoutLog(dep, fx.Invoke(stressAttackF)).Run()
 		},
 	}
@@ -77,6 +94,111 @@ func NewStressMemCommand(dep fx.Option, options *core.StressCommand) *cobra.Comm
 	cmd.Flags().IntVarP(&options.Workers, "workers", "w", 1, "Workers specifies N workers to apply the stressor.")
 	cmd.Flags().StringVarP(&options.Size, "size", "s", "", "Size specifies N bytes consumed per vm worker, default is the total available memory. One can specify the size as % of total available memory or in units of B, KB/KiB, MB/MiB, GB/GiB, TB/TiB..")
 	cmd.Flags().StringSliceVarP(&options.Options, "options", "o", []string{}, "extend stress-ng options.")
+	setProfileFlags(cmd, options, &profileFile)
+
+	return cmd
+}
+
+func setProfileFlags(cmd *cobra.Command, options *core.StressCommand, profileFile *string) {
+	cmd.Flags().StringArrayVar(&options.Profile, "profile", nil, "the load profile which changes the load percent over time, formatted as <shape>:<from>-<to>:<duration>[:<count>], the shape can be ramp, step or sine, such as ramp:10-90:5m. It can be specified multiple times to apply the segments one after another.")
+	cmd.Flags().StringVar(profileFile, "profile-file", "", "the file of the load profile, which has a segment per line")
+}
+
+// readProfileFile appends the segments in the profile file to the load profile of the options.
+func readProfileFile(options *core.StressCommand, profileFile string) {
+	if len(profileFile) == 0 {
+		return
+	}
+
+	f, err := os.Open(profileFile)
+	if err != nil {
+		utils.ExitWithError(utils.ExitBadArgs, err)
+	}
+	defer f.Close()
+
+	profile, err := core.ReadLoadProfile(f)
+	if err != nil {
+		utils.ExitWithError(utils.ExitBadArgs, err)
+	}
+	options.Profile = append(options.Profile, profile...)
+}
+
+// NewStressLoadProfileCommand creates the hidden command which generates the load following
+// a profile, it runs in the background until the experiment is recovered.
+func NewStressLoadProfileCommand() *cobra.Command {
+	var (
+		action  string
+		workers int
+		specs   []string
+	)
+	cmd := &cobra.Command{
+		Use:    chaosd.LoadProfileCommand,
+		Hidden: true,
+		Run: func(*cobra.Command, []string) {
+			profile, err := core.ParseLoadProfile(specs)
+			if err != nil {
+				utils.ExitWithError(utils.ExitBadArgs, err)
+			}
+
+			if err := loadgen.Run(signalContext(), action, workers, profile); err != nil {
+				utils.ExitWithError(utils.ExitError, err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&action, "action", core.StressCPUAction, "the stress action, cpu or mem")
+	cmd.Flags().IntVar(&workers, "workers", 1, "the number of CPU workers")
+	cmd.Flags().StringArrayVar(&specs, "profile", nil, "the load profile")
+
+	return cmd
+}
+
+// newStressWorkersCommand creates the command of the stressor which is only configured by its workers.
+func newStressWorkersCommand(dep fx.Option, options *core.StressCommand, action string, short string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   action + " [options]",
+		Short: short,
+		Run: func(*cobra.Command, []string) {
+			options.Action = action
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(stressAttackF)).Run()
+		},
+	}
+
+	cmd.Flags().IntVarP(&options.Workers, "workers", "w", 1, "Workers specifies N workers to apply the stressor.")
+	cmd.Flags().StringSliceVarP(&options.Options, "options", "o", []string{}, "extend stress-ng options.")
+
+	return cmd
+}
+
+func NewStressHDDCommand(dep fx.Option, options *core.StressCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hdd [options]",
+		Short: "continuously write, read and remove temporary files",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.StressHDDAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(stressAttackF)).Run()
+		},
+	}
+
+	cmd.Flags().IntVarP(&options.Workers, "workers", "w", 1, "Workers specifies N workers to apply the stressor.")
+	cmd.Flags().StringVarP(&options.HDDBytes, "bytes", "b", "", "Bytes specifies N bytes written per hdd worker, default is 1GB. One can specify the size as % of free space on the file system or in units of B, KB/KiB, MB/MiB, GB/GiB, TB/TiB.")
+	cmd.Flags().StringSliceVarP(&options.Options, "options", "o", []string{}, "extend stress-ng options.")
+
+	return cmd
+}
+
+func NewStressCustomCommand(dep fx.Option, options *core.StressCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "custom [options]",
+		Short: "continuously run the stressors supported by stress-ng",
+		Run: func(*cobra.Command, []string) {
+			options.Action = core.StressCustomAction
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(stressAttackF)).Run()
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&options.Stressors, "stressor", nil, "the stressors to run, formatted as <name>[:<workers>] such as matrix:2, workers is 1 by default and 0 means one worker per CPU.")
+	cmd.Flags().StringSliceVarP(&options.Options, "options", "o", []string{}, "extend stress-ng options.")
 
 	return cmd
 }
@@ -91,5 +213,5 @@ func stressAttackF(chaos *chaosd.Server, options *core.StressCommand) {
 		utils.ExitWithError(utils.ExitError, err)
 	}
 
-	utils.NormalExit(fmt.Sprintf("Attack stress %s successfully, uid: %s", options.Action, uid))
+	attackSuccessExit(chaos, options, uid, fmt.Sprintf("Attack stress %s successfully, uid: %s", options.Action, uid))
 }
@@ -0,0 +1,117 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/chaos-mesh/chaosd/cmd/server"
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+)
+
+// fakeStressng forks a worker like stress-ng, which is stopped along with it, and removes
+// its temporary file when it is terminated like the hdd stressor
+const fakeStressng = `#!/bin/sh
+touch "$0.tmp"
+trap 'rm -f "$0.tmp"; exit' TERM
+sleep 7001 &
+wait
+`
+
+func TestServer_StressRecover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaosd-stress")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "stress-ng"), []byte(fakeStressng), 0755)) {
+		return
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+
+	fxtest.New(
+		t,
+		server.Module,
+		fx.Invoke(func(s *chaosd.Server) {
+			attack := func() (string, *core.StressCommand) {
+				options := core.NewStressCommand()
+				options.Action = core.StressIOAction
+				options.Workers = 1
+				uid, err := s.ExecuteAttack(chaosd.StressAttack, options)
+				assert.NoError(t, err)
+				assert.NotZero(t, options.StressngStartTime)
+				assert.Equal(t, options.StressngPid, options.StressngPgid)
+				time.Sleep(100 * time.Millisecond)
+				return uid, options
+			}
+
+			uid, options := attack()
+			worker := findProcess(t, "sleep 7001")
+			assert.NoError(t, s.RecoverAttack(uid))
+			// the worker forked by stress-ng is stopped along with it
+			for _, pid := range []int32{options.StressngPid, worker} {
+				assert.Eventually(t, func() bool {
+					return !processRunning(pid)
+				}, time.Second, 10*time.Millisecond)
+			}
+			_, err := os.Stat(filepath.Join(dir, "stress-ng.tmp"))
+			assert.True(t, os.IsNotExist(err))
+
+			uid, options = attack()
+			assert.NoError(t, syscall.Kill(-int(options.StressngPgid), syscall.SIGKILL))
+			time.Sleep(100 * time.Millisecond)
+			assert.NoError(t, s.RecoverAttack(uid))
+			exps, err := s.Search(&core.SearchCommand{UID: uid})
+			if assert.NoError(t, err) && assert.Len(t, exps, 1) {
+				assert.Equal(t, core.Destroyed, exps[0].Status)
+				assert.Contains(t, exps[0].Message, "already_recovered")
+			}
+		}),
+	)
+}
+
+func findProcess(t *testing.T, cmdline string) int32 {
+	procs, err := process.Processes()
+	assert.NoError(t, err)
+	for _, p := range procs {
+		if c, _ := p.Cmdline(); c == cmdline {
+			return p.Pid
+		}
+	}
+	t.Fatalf("process %s not found", cmdline)
+	return 0
+}
+
+// processRunning returns whether the process exists and isn't a zombie.
+func processRunning(pid int32) bool {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return false
+	}
+	status, err := p.Status()
+	return err == nil && status != "Z"
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/chaos-mesh/chaosd/cmd/server"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+type doctorOptions struct {
+	RemoveOrphans bool
+	Reapply       bool
+}
+
+func NewDoctorCommand() *cobra.Command {
+	options := &doctorOptions{}
+	dep := fx.Options(
+		server.Module,
+		fx.Provide(func() *doctorOptions {
+			return options
+		}),
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the drift between the stored network rules and the ones installed in the kernel",
+		Run: func(cmd *cobra.Command, args []string) {
+			utils.FxNewAppWithoutLog(dep, fx.Invoke(doctorCommandFunc)).Run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.RemoveOrphans, "remove-orphans", false,
+		"remove the ipsets, iptables chains, qdiscs and IFB devices created by chaosd which are not stored")
+	cmd.Flags().BoolVar(&options.Reapply, "reapply", false, "apply the stored network rules again")
+
+	return cmd
+}
+
+func doctorCommandFunc(chaos *chaosd.Server, options *doctorOptions) {
+	drifts, err := chaos.DiagnoseNetwork()
+	if err != nil {
+		utils.ExitWithError(utils.ExitError, err)
+	}
+
+	if len(drifts) == 0 {
+		utils.NormalExit("No drift is found between the stored network rules and the kernel")
+	}
+
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Namespace", "Kind", "Name", "Drift"})
+	tw.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	tw.SetAlignment(3)
+	tw.SetRowSeparator("-")
+	tw.SetCenterSeparator(" ")
+	tw.SetColumnSeparator(" ")
+
+	for _, drift := range drifts {
+		namespace, state := "host", "missing"
+		if len(drift.ContainerID) > 0 {
+			namespace = drift.ContainerID
+		}
+		if drift.Orphaned {
+			state = "orphaned"
+		}
+		tw.Append([]string{namespace, drift.Kind, drift.Name, state})
+	}
+	tw.Render()
+
+	if options.RemoveOrphans {
+		if err := chaos.RemoveNetworkOrphans(drifts); err != nil {
+			utils.ExitWithError(utils.ExitError, err)
+		}
+		fmt.Println("Orphaned network rules are removed")
+	}
+
+	if options.Reapply {
+		if err := chaos.ReapplyNetwork(); err != nil {
+			utils.ExitWithError(utils.ExitError, err)
+		}
+		fmt.Println("Stored network rules are applied again")
+	}
+
+	utils.NormalExit("")
+}
@@ -23,6 +23,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/chaos-mesh/chaosd/cmd/attack"
+	"github.com/chaos-mesh/chaosd/cmd/doctor"
 	"github.com/chaos-mesh/chaosd/cmd/recover"
 	"github.com/chaos-mesh/chaosd/cmd/search"
 	"github.com/chaos-mesh/chaosd/cmd/server"
@@ -41,12 +42,14 @@ var rootCmd = &cobra.Command{
 func init() {
 	cobra.OnInitialize(setLogLevel)
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "", "", "the log level of chaosd, the value can be 'debug', 'info', 'warn' and 'error'")
+	server.SetContainerRuntimeFlags(rootCmd.PersistentFlags())
 
 	rootCmd.AddCommand(
 		server.NewServerCommand(),
 		attack.NewAttackCommand(),
 		recover.NewRecoverCommand(),
 		search.NewSearchCommand(),
+		doctor.NewDoctorCommand(),
 		version.NewVersionCommand(),
 	)
 }
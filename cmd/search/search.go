@@ -56,6 +56,7 @@ func NewSearchCommand() *cobra.Command {
 	cmd.Flags().Uint32VarP(&options.Limit, "limit", "l", 0, "limit the count of attacks")
 	cmd.Flags().BoolVar(&options.Asc, "asc", false, "order by CreateTime, "+
 		"default value is false that means order by CreateTime desc")
+	cmd.Flags().BoolVar(&options.Runs, "runs", false, "list the runs of the scheduled attack UID")
 
 	return cmd
 }
@@ -65,18 +66,17 @@ func searchCommandFunc(chaos *chaosd.Server, options *core.SearchCommand) {
 		utils.ExitWithError(utils.ExitBadArgs, err)
 	}
 
+	if options.Runs {
+		searchRuns(chaos, options.UID)
+		return
+	}
+
 	exps, err := chaos.Search(options)
 	if err != nil {
 		utils.ExitWithError(utils.ExitError, err)
 	}
 
-	tw := tablewriter.NewWriter(os.Stdout)
-	tw.SetHeader([]string{"UID", "Kind", "Action", "Status", "Create Time", "Configuration"})
-	tw.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
-	tw.SetAlignment(3)
-	tw.SetRowSeparator("-")
-	tw.SetCenterSeparator(" ")
-	tw.SetColumnSeparator(" ")
+	tw := newTableWriter([]string{"UID", "Kind", "Action", "Status", "Create Time", "Configuration"})
 
 	for _, exp := range exps {
 		tw.Append([]string{
@@ -88,3 +88,38 @@ func searchCommandFunc(chaos *chaosd.Server, options *core.SearchCommand) {
 
 	utils.NormalExit("")
 }
+
+func searchRuns(chaos *chaosd.Server, uid string) {
+	runs, err := chaos.SearchRuns(uid)
+	if err != nil {
+		utils.ExitWithError(utils.ExitError, err)
+	}
+
+	tw := newTableWriter([]string{"Run UID", "Status", "Start Time", "Finish Time", "Duration", "Message"})
+	for _, run := range runs {
+		finishedAt, duration := "", ""
+		if !run.FinishedAt.IsZero() {
+			finishedAt = run.FinishedAt.Format(time.RFC3339)
+			duration = run.FinishedAt.Sub(run.StartAt).String()
+		}
+		tw.Append([]string{
+			run.UID, run.Status, run.StartAt.Format(time.RFC3339), finishedAt, duration, run.Message,
+		})
+	}
+
+	tw.Render()
+
+	utils.NormalExit("")
+}
+
+func newTableWriter(header []string) *tablewriter.Table {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader(header)
+	tw.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	tw.SetAlignment(3)
+	tw.SetRowSeparator("-")
+	tw.SetCenterSeparator(" ")
+	tw.SetColumnSeparator(" ")
+
+	return tw
+}
@@ -15,9 +15,12 @@ package server
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/fx"
 
 	"github.com/chaos-mesh/chaosd/pkg/config"
+	"github.com/chaos-mesh/chaosd/pkg/container"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
 	"github.com/chaos-mesh/chaosd/pkg/server/httpserver"
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 	"github.com/chaos-mesh/chaosd/pkg/version"
@@ -32,7 +35,7 @@ func NewServerCommand() *cobra.Command {
 
 	cmd.Flags().IntVarP(&conf.ListenPort, "port", "p", 31767, "listen port of the Chaosd Server")
 	cmd.Flags().StringVarP(&conf.ListenHost, "host", "a", "0.0.0.0", "listen host of the Chaosd Server")
-	cmd.Flags().StringVarP(&conf.Runtime, "runtime", "r", "docker", "current container runtime")
+	cmd.Flags().StringVarP(&conf.Runtime, "runtime", "r", "docker", "current container runtime, supported runtime: docker, containerd, crio")
 	cmd.Flags().BoolVar(&conf.EnablePprof, "enable-pprof", true, "enable pprof")
 	cmd.Flags().IntVar(&conf.PprofPort, "pprof-port", 31766, "listen port of the pprof server")
 	cmd.Flags().StringVarP(&conf.Platform, "platform", "f", "local", "platform to deploy, default: local, supported platform: local, kubernetes")
@@ -40,6 +43,17 @@ func NewServerCommand() *cobra.Command {
 	return cmd
 }
 
+// SetContainerRuntimeFlags sets the flags to connect to the container runtimes,
+// they are used by both the server and the attack commands.
+func SetContainerRuntimeFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&conf.DockerSocket, "docker-socket", container.DefaultDockerSocket, "the address of the docker daemon")
+	flags.StringVar(&conf.ContainerdSocket, "containerd-socket", container.DefaultContainerdSocket, "the path of the containerd socket")
+	flags.StringVar(&conf.ContainerdNamespace, "containerd-namespace", container.DefaultContainerdNamespace,
+		"the containerd namespace of the containers")
+	flags.StringVar(&conf.CRISocket, "cri-socket", container.DefaultCRISocket,
+		"the path of the CRI socket of CRI-O, or any runtime serving the CRI gRPC API")
+}
+
 var conf = config.Config{
 	Platform: config.LocalPlatform,
 	Runtime:  "docker",
@@ -54,6 +68,8 @@ func serverCommandFunc(cmd *cobra.Command, args []string) {
 
 	app := utils.FxNewAppWithoutLog(
 		Module,
+		fx.Invoke(chaosd.Reconcile),
+		fx.Invoke(chaosd.Restore),
 		fx.Invoke(httpserver.Register),
 	)
 	app.Run()
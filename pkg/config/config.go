@@ -32,6 +32,15 @@ type Config struct {
 	EnablePprof bool
 	PprofPort   int
 	Platform    string
+
+	// DockerSocket is the address of the docker daemon.
+	DockerSocket string
+	// ContainerdSocket is the path of the containerd socket.
+	ContainerdSocket string
+	// ContainerdNamespace is the containerd namespace of the containers.
+	ContainerdNamespace string
+	// CRISocket is the path of the CRI socket of CRI-O, or any runtime serving the CRI gRPC API.
+	CRISocket string
 }
 
 // Parse parses flag definitions from the argument list.
@@ -81,7 +90,7 @@ func checkPlatform(platform string) bool {
 	return false
 }
 
-var supportRuntimes = []string{"docker", "runtime"}
+var supportRuntimes = []string{"docker", "containerd", "crio"}
 
 func checkRuntime(runtime string) bool {
 	for _, r := range supportRuntimes {
@@ -17,9 +17,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
 	"github.com/docker/docker/api/types"
 	dockerclient "github.com/docker/docker/client"
 
@@ -30,14 +33,17 @@ import (
 const (
 	containerRuntimeDocker     = "docker"
 	containerRuntimeContainerd = "containerd"
+	containerRuntimeCrio       = "crio"
 
-	defaultDockerSocket  = "unix:///var/run/docker.sock"
+	DefaultDockerSocket  = "unix:///var/run/docker.sock"
 	dockerProtocolPrefix = "docker://"
 
-	// TODO(yeya24): make socket and ns configurable
-	defaultContainerdSocket  = "/run/containerd/containerd.sock"
-	containerdProtocolPrefix = "containerd://"
-	containerdDefaultNS      = "k8s.io"
+	DefaultContainerdSocket    = "/run/containerd/containerd.sock"
+	containerdProtocolPrefix   = "containerd://"
+	DefaultContainerdNamespace = "k8s.io"
+
+	DefaultCRISocket   = "/var/run/crio/crio.sock"
+	crioProtocolPrefix = "cri-o://"
 )
 
 // CRIClient represents a struct which can give you information about container runtime
@@ -45,40 +51,73 @@ type CRIClient interface {
 	GetPidFromContainerID(ctx context.Context, containerID string) (uint32, error)
 	ContainerKillByContainerID(ctx context.Context, containerID string) error
 	FormatContainerID(ctx context.Context, containerID string) (string, error)
+	ContainerPauseByContainerID(ctx context.Context, containerID string) error
+	ContainerUnpauseByContainerID(ctx context.Context, containerID string) error
+	ContainerRestartByContainerID(ctx context.Context, containerID string) error
 }
 
 // NewCRIClient creates a container runtime information client.
 func NewCRIClient(conf *config.Config) (CRIClient, error) {
-	// TODO: support more container runtime
-
 	var cli CRIClient
 	switch conf.Runtime {
 	case containerRuntimeDocker:
-		client, err := newDockerClient(defaultDockerSocket, "", nil, nil)
+		client, err := newDockerClient(orDefault(conf.DockerSocket, DefaultDockerSocket), "", nil, nil)
 		if err != nil {
 			return nil, err
 		}
 		cli = DockerClient{client}
 
 	case containerRuntimeContainerd:
-		// TODO(yeya24): add more options?
-		client, err := newContainerdClient(defaultContainerdSocket, containerd.WithDefaultNamespace(containerdDefaultNS))
+		client, err := newContainerdClient(orDefault(conf.ContainerdSocket, DefaultContainerdSocket),
+			containerd.WithDefaultNamespace(orDefault(conf.ContainerdNamespace, DefaultContainerdNamespace)))
 		if err != nil {
 			return nil, err
 		}
 		cli = ContainerdClient{client}
 
+	case containerRuntimeCrio:
+		client, err := newRuntimeServiceClient(orDefault(conf.CRISocket, DefaultCRISocket))
+		if err != nil {
+			return nil, err
+		}
+		cli = CRIRuntimeClient{client}
+
 	default:
-		return nil, fmt.Errorf("only docker and containerd is supported, but got %s", conf.Runtime)
+		return nil, fmt.Errorf("only docker, containerd and crio are supported, but got %s", conf.Runtime)
 	}
 
 	return cli, nil
 }
 
+func orDefault(value string, defaultValue string) string {
+	if len(value) == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// RuntimeOf returns the container runtime according to the protocol prefix of the container ID.
+func RuntimeOf(containerID string) (string, error) {
+	switch {
+	case strings.HasPrefix(containerID, dockerProtocolPrefix):
+		return containerRuntimeDocker, nil
+	case strings.HasPrefix(containerID, containerdProtocolPrefix):
+		return containerRuntimeContainerd, nil
+	case strings.HasPrefix(containerID, crioProtocolPrefix):
+		return containerRuntimeCrio, nil
+	default:
+		return "", fmt.Errorf("container id %s should start with %s, %s or %s",
+			containerID, dockerProtocolPrefix, containerdProtocolPrefix, crioProtocolPrefix)
+	}
+}
+
 // DockerClientInterface represents the DockerClient, it's used to simply unit test
 type DockerClientInterface interface {
 	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
 	ContainerKill(ctx context.Context, containerID, signal string) error
+	ContainerPause(ctx context.Context, containerID string) error
+	ContainerUnpause(ctx context.Context, containerID string) error
+	ContainerRestart(ctx context.Context, containerID string, timeout *time.Duration) error
 }
 
 // DockerClient can get information from docker
@@ -212,3 +251,103 @@ func (c ContainerdClient) ContainerKillByContainerID(ctx context.Context, contai
 
 	return err
 }
+
+// ContainerPauseByContainerID pauses container according to container id
+func (c DockerClient) ContainerPauseByContainerID(ctx context.Context, containerID string) error {
+	id, err := c.FormatContainerID(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.client.ContainerPause(ctx, id)
+}
+
+// ContainerUnpauseByContainerID unpauses container according to container id
+func (c DockerClient) ContainerUnpauseByContainerID(ctx context.Context, containerID string) error {
+	id, err := c.FormatContainerID(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	return c.client.ContainerUnpause(ctx, id)
+}
+
+// ContainerRestartByContainerID restarts container according to container id
+func (c DockerClient) ContainerRestartByContainerID(ctx context.Context, containerID string) error {
+	id, err := c.FormatContainerID(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	// nil timeout means the default timeout of docker before killing the container
+	return c.client.ContainerRestart(ctx, id, nil)
+}
+
+// ContainerPauseByContainerID pauses container according to container id
+func (c ContainerdClient) ContainerPauseByContainerID(ctx context.Context, containerID string) error {
+	task, err := c.loadTask(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	return task.Pause(ctx)
+}
+
+// ContainerUnpauseByContainerID unpauses container according to container id
+func (c ContainerdClient) ContainerUnpauseByContainerID(ctx context.Context, containerID string) error {
+	task, err := c.loadTask(ctx, containerID)
+	if err != nil {
+		return err
+	}
+
+	return task.Resume(ctx)
+}
+
+// ContainerRestartByContainerID restarts container according to container id, the task of
+// the container is killed and started again without the standard IO of the original one.
+func (c ContainerdClient) ContainerRestartByContainerID(ctx context.Context, containerID string) error {
+	id, err := c.FormatContainerID(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	container, err := c.client.LoadContainer(ctx, id)
+	if err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	exitCh, err := task.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if err = task.Kill(ctx, syscall.SIGKILL); err != nil {
+		return err
+	}
+	<-exitCh
+	if _, err = task.Delete(ctx); err != nil {
+		return err
+	}
+
+	task, err = container.NewTask(ctx, cio.NullIO)
+	if err != nil {
+		return err
+	}
+
+	return task.Start(ctx)
+}
+
+func (c ContainerdClient) loadTask(ctx context.Context, containerID string) (containerd.Task, error) {
+	id, err := c.FormatContainerID(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	container, err := c.client.LoadContainer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.Task(ctx, nil)
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	. "github.com/onsi/gomega"
+)
+
+// fakeDockerClient records the calls in the form of "action id"
+type fakeDockerClient struct {
+	calls []string
+}
+
+func (f *fakeDockerClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return types.ContainerJSON{}, nil
+}
+
+func (f *fakeDockerClient) ContainerKill(ctx context.Context, containerID, signal string) error {
+	f.calls = append(f.calls, "kill "+containerID)
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerPause(ctx context.Context, containerID string) error {
+	f.calls = append(f.calls, "pause "+containerID)
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerUnpause(ctx context.Context, containerID string) error {
+	f.calls = append(f.calls, "unpause "+containerID)
+	return nil
+}
+
+func (f *fakeDockerClient) ContainerRestart(ctx context.Context, containerID string, timeout *time.Duration) error {
+	f.calls = append(f.calls, "restart "+containerID)
+	return nil
+}
+
+func TestDockerClientContainerActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	fake := &fakeDockerClient{}
+	cli := DockerClient{client: fake}
+	ctx := context.Background()
+
+	g.Expect(cli.ContainerPauseByContainerID(ctx, "docker://a1b2c3")).Should(Succeed())
+	g.Expect(cli.ContainerUnpauseByContainerID(ctx, "docker://a1b2c3")).Should(Succeed())
+	g.Expect(cli.ContainerRestartByContainerID(ctx, "docker://a1b2c3")).Should(Succeed())
+	g.Expect(cli.ContainerKillByContainerID(ctx, "docker://a1b2c3")).Should(Succeed())
+	g.Expect(fake.calls).Should(Equal([]string{"pause a1b2c3", "unpause a1b2c3", "restart a1b2c3", "kill a1b2c3"}))
+
+	g.Expect(cli.ContainerPauseByContainerID(ctx, "containerd://a1b2c3")).ShouldNot(Succeed())
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+const (
+	criDialTimeout = 10 * time.Second
+	// criStopTimeout is the grace period before the container is killed when it is restarted.
+	criStopTimeout = 10
+)
+
+// CRIRuntimeClient can get information from CRI-O, or any runtime serving the CRI gRPC API
+type CRIRuntimeClient struct {
+	client runtimeapi.RuntimeServiceClient
+}
+
+// FormatContainerID strips protocol prefix from the container ID
+func (c CRIRuntimeClient) FormatContainerID(ctx context.Context, containerID string) (string, error) {
+	if !strings.HasPrefix(containerID, crioProtocolPrefix) {
+		return "", fmt.Errorf("container id %s is not a cri-o container id", containerID)
+	}
+	return containerID[len(crioProtocolPrefix):], nil
+}
+
+// GetPidFromContainerID fetches PID according to container id
+func (c CRIRuntimeClient) GetPidFromContainerID(ctx context.Context, containerID string) (uint32, error) {
+	id, err := c.FormatContainerID(ctx, containerID)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{
+		ContainerId: id,
+		Verbose:     true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// the verbose information is like {"info": "{\"pid\": 1234, ...}"}
+	var info struct {
+		Pid uint32 `json:"pid"`
+	}
+	if err := json.Unmarshal([]byte(resp.Info["info"]), &info); err != nil {
+		return 0, fmt.Errorf("fail to get pid from container info: %v", err)
+	}
+	if info.Pid == 0 {
+		return 0, fmt.Errorf("fail to get pid from container info of %s", containerID)
+	}
+
+	return info.Pid, nil
+}
+
+// ContainerKillByContainerID kills container according to container id
+func (c CRIRuntimeClient) ContainerKillByContainerID(ctx context.Context, containerID string) error {
+	return c.stopContainer(ctx, containerID, 0)
+}
+
+// ContainerPauseByContainerID is not supported, CRI has no API to pause containers
+func (c CRIRuntimeClient) ContainerPauseByContainerID(ctx context.Context, containerID string) error {
+	return fmt.Errorf("pausing container %s is not supported by CRI", containerID)
+}
+
+// ContainerUnpauseByContainerID is not supported, CRI has no API to unpause containers
+func (c CRIRuntimeClient) ContainerUnpauseByContainerID(ctx context.Context, containerID string) error {
+	return fmt.Errorf("unpausing container %s is not supported by CRI", containerID)
+}
+
+// ContainerRestartByContainerID stops container gracefully according to container id. The stopped
+// containers can't be started again through CRI, they are restarted by kubelet with the restart policy.
+func (c CRIRuntimeClient) ContainerRestartByContainerID(ctx context.Context, containerID string) error {
+	return c.stopContainer(ctx, containerID, criStopTimeout)
+}
+
+func (c CRIRuntimeClient) stopContainer(ctx context.Context, containerID string, timeout int64) error {
+	id, err := c.FormatContainerID(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.StopContainer(ctx, &runtimeapi.StopContainerRequest{
+		ContainerId: id,
+		Timeout:     timeout,
+	})
+	return err
+}
+
+// newRuntimeServiceClient connects to the CRI gRPC endpoint on the unix socket
+func newRuntimeServiceClient(socket string) (runtimeapi.RuntimeServiceClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), criDialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, strings.TrimPrefix(socket, "unix://"),
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.FailOnNonTempDialError(true),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", addr)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("fail to connect to CRI socket %s: %v", socket, err)
+	}
+
+	return runtimeapi.NewRuntimeServiceClient(conn), nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/chaos-mesh/chaosd/pkg/config"
+)
+
+// fakeRuntimeService serves a container "a1b2c3" with PID 1234
+type fakeRuntimeService struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+
+	stopped map[string]int64
+}
+
+func (f *fakeRuntimeService) ContainerStatus(ctx context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	if req.ContainerId != "a1b2c3" {
+		return nil, status.Errorf(codes.NotFound, "container %s not found", req.ContainerId)
+	}
+	resp := &runtimeapi.ContainerStatusResponse{
+		Status: &runtimeapi.ContainerStatus{Id: req.ContainerId},
+	}
+	if req.Verbose {
+		resp.Info = map[string]string{"info": `{"pid": 1234, "sandboxID": "d4e5f6"}`}
+	}
+	return resp, nil
+}
+
+func (f *fakeRuntimeService) StopContainer(ctx context.Context, req *runtimeapi.StopContainerRequest) (*runtimeapi.StopContainerResponse, error) {
+	f.stopped[req.ContainerId] = req.Timeout
+	return &runtimeapi.StopContainerResponse{}, nil
+}
+
+func TestCRIRuntimeClient(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "chaosd-cri")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "crio.sock")
+	lis, err := net.Listen("unix", socket)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	fake := &fakeRuntimeService{stopped: make(map[string]int64)}
+	server := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(server, fake)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	cli, err := NewCRIClient(&config.Config{Runtime: containerRuntimeCrio, CRISocket: "unix://" + socket})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	ctx := context.Background()
+
+	pid, err := cli.GetPidFromContainerID(ctx, "cri-o://a1b2c3")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(pid).Should(Equal(uint32(1234)))
+
+	_, err = cli.GetPidFromContainerID(ctx, "cri-o://notfound")
+	g.Expect(err).Should(HaveOccurred())
+	_, err = cli.GetPidFromContainerID(ctx, "docker://a1b2c3")
+	g.Expect(err).Should(HaveOccurred())
+
+	g.Expect(cli.ContainerKillByContainerID(ctx, "cri-o://a1b2c3")).Should(Succeed())
+	g.Expect(fake.stopped).Should(HaveKeyWithValue("a1b2c3", int64(0)))
+	g.Expect(cli.ContainerRestartByContainerID(ctx, "cri-o://d4e5f6")).Should(Succeed())
+	g.Expect(fake.stopped).Should(HaveKeyWithValue("d4e5f6", int64(criStopTimeout)))
+	g.Expect(cli.ContainerPauseByContainerID(ctx, "cri-o://a1b2c3")).ShouldNot(Succeed())
+}
+
+func TestRuntimeOf(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for id, runtime := range map[string]string{
+		"docker://a1b2c3":     containerRuntimeDocker,
+		"containerd://a1b2c3": containerRuntimeContainerd,
+		"cri-o://a1b2c3":      containerRuntimeCrio,
+	} {
+		r, err := RuntimeOf(id)
+		g.Expect(err).ShouldNot(HaveOccurred())
+		g.Expect(r).Should(Equal(runtime))
+	}
+
+	_, err := RuntimeOf("a1b2c3")
+	g.Expect(err).Should(HaveOccurred())
+}
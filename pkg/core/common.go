@@ -13,9 +13,19 @@
 
 package core
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
 type AttackConfig interface {
 	Validate() error
 	Cron() string
+	// ScheduleDuration returns how long the attack lasts before it is
+	// recovered automatically, nil means it lasts until recovered manually.
+	ScheduleDuration() (*time.Duration, error)
 	// String is replacement of .Action
 	String() string
 	// RecoverData is replacement of earlier .String()
@@ -37,6 +47,23 @@ func (config SchedulerConfig) Cron() string {
 	return config.Schedule
 }
 
+func (config SchedulerConfig) ScheduleDuration() (*time.Duration, error) {
+	if len(config.Duration) == 0 {
+		return nil, nil
+	}
+
+	duration, err := time.ParseDuration(config.Duration)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("duration %s not valid", config.Duration))
+	}
+
+	if duration <= 0 {
+		return nil, errors.Errorf("duration %s must be positive", config.Duration)
+	}
+
+	return &duration, nil
+}
+
 type CommonAttackConfig struct {
 	SchedulerConfig
 
@@ -0,0 +1,69 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/container"
+)
+
+const (
+	ContainerKillAction    = "kill"
+	ContainerPauseAction   = "pause"
+	ContainerRestartAction = "restart"
+)
+
+var _ AttackConfig = &ContainerCommand{}
+
+type ContainerCommand struct {
+	CommonAttackConfig
+
+	// ContainerID is the container ID with the runtime prefix, such as docker://xxx, containerd://xxx or cri-o://xxx.
+	ContainerID string `json:"container_id"`
+}
+
+func (c ContainerCommand) Validate() error {
+	switch c.Action {
+	case ContainerKillAction, ContainerPauseAction, ContainerRestartAction:
+	default:
+		return errors.Errorf("container action %s not supported", c.Action)
+	}
+
+	if len(c.ContainerID) == 0 {
+		return errors.New("container id not provided")
+	}
+
+	if _, err := container.RuntimeOf(c.ContainerID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (c ContainerCommand) RecoverData() string {
+	data, _ := json.Marshal(c)
+
+	return string(data)
+}
+
+func NewContainerCommand() *ContainerCommand {
+	return &ContainerCommand{
+		CommonAttackConfig: CommonAttackConfig{
+			Kind: ContainerAttack,
+		},
+	}
+}
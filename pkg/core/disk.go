@@ -17,6 +17,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
@@ -25,6 +28,14 @@ const (
 	DiskFillAction         = "fill"
 	DiskWritePayloadAction = "write-payload"
 	DiskReadPayloadAction  = "read-payload"
+	// DiskSustainedPayloadAction reads and writes a file at a target rate until the attack is recovered.
+	DiskSustainedPayloadAction = "sustained-payload"
+	// DiskFaultAction injects latency and errors into the io of a directory by a FUSE passthrough mount.
+	DiskFaultAction = "fault"
+	// DiskInodeFillAction creates empty files until the inode usage of the disk reaches a target.
+	DiskInodeFillAction = "inode-fill"
+
+	DefaultDiskPayloadBlockSize = "4K"
 )
 
 type DiskOption struct {
@@ -36,14 +47,93 @@ type DiskOption struct {
 	FillByFallocate   bool   `json:"fill_by_fallocate"`
 	DestroyFile       bool   `json:"destroy_file"`
 	PayloadProcessNum uint8  `json:"payload_process_num"`
+
+	// FillFile is the file created by chaosd to fill the disk or for the sustained payload,
+	// which is owned by the experiment and removed on recovery. A file given by path which already
+	// exists is never recorded here.
+	FillFile string `json:"fill_file,omitempty"`
+	// FillDir is the directory created by chaosd to hold the files of the inode fill,
+	// it's removed along with the files on recovery.
+	FillDir string `json:"fill_dir,omitempty"`
+
+	// UntilUsed fills the disk until its usage reaches the percent, such as 95%.
+	// It's the usage of inodes instead of bytes for the inode fill.
+	UntilUsed string `json:"until_used,omitempty"`
+	// LeaveFree fills the disk until only the size is free, such as 500MB.
+	LeaveFree string `json:"leave_free,omitempty"`
+	// Watch tops the fill file up in the background when other processes free space,
+	// so that the disk stays at the target of UntilUsed or LeaveFree.
+	Watch         bool   `json:"watch,omitempty"`
+	WatchInterval string `json:"watch_interval,omitempty"`
+	WatcherPid    int32  `json:"watcher_pid,omitempty"`
+	// WatcherStartTime is the create time of the watcher process in milliseconds since the epoch.
+	WatcherStartTime int64 `json:"watcher_start_time,omitempty"`
+
+	// ReadPercent is the percent of reads of the sustained payload, the others are writes.
+	ReadPercent int `json:"read_percent,omitempty"`
+	// BlockSize is the size of each read or write of the sustained payload.
+	BlockSize string `json:"block_size,omitempty"`
+	// Rate limits the throughput of the sustained payload per second, such as 10MB.
+	Rate string `json:"rate,omitempty"`
+	// IOPS limits the read and write operations of the sustained payload per second.
+	IOPS int64 `json:"iops,omitempty"`
+	// Random reads and writes the blocks in random order instead of sequentially.
+	Random           bool  `json:"random,omitempty"`
+	PayloadPid       int32 `json:"payload_pid,omitempty"`
+	PayloadStartTime int64 `json:"payload_start_time,omitempty"`
+
+	// Latency delays the faulted operations of the fault action, such as 200ms.
+	Latency string `json:"latency,omitempty"`
+	// Errno fails the faulted operations of the fault action, such as EIO or 5.
+	Errno string `json:"errno,omitempty"`
+	// Methods are the faulted operations of the fault action, all operations if empty.
+	Methods []string `json:"methods,omitempty"`
+	// MountPath is where the FUSE file system of the fault action is mounted, the path itself
+	// is mounted over if it's empty. The faults are only injected into the io through MountPath.
+	MountPath      string `json:"mount_path,omitempty"`
+	FaultPid       int32  `json:"fault_pid,omitempty"`
+	FaultStartTime int64  `json:"fault_start_time,omitempty"`
 }
 
+// DiskFaultMethods are the operations which the fault action can inject faults into.
+var DiskFaultMethods = []string{"open", "create", "read", "write", "fsync", "flush", "mkdir", "unlink", "rmdir", "rename"}
+
+var diskFaultErrnos = map[string]syscall.Errno{
+	"EIO":    syscall.EIO,
+	"ENOSPC": syscall.ENOSPC,
+	"EROFS":  syscall.EROFS,
+	"EACCES": syscall.EACCES,
+	"EPERM":  syscall.EPERM,
+	"EDQUOT": syscall.EDQUOT,
+	"EBUSY":  syscall.EBUSY,
+	"EAGAIN": syscall.EAGAIN,
+	"EINTR":  syscall.EINTR,
+	"ENOENT": syscall.ENOENT,
+}
+
+const DefaultDiskWatchInterval = "10s"
+
 var _ AttackConfig = &DiskOption{}
 
 func (d *DiskOption) Validate() error {
 	var byteSize uint64
 	var err error
-	if d.Size == "" {
+	if d.Action == DiskFaultAction {
+		if err = d.validateFault(); err != nil {
+			return err
+		}
+	} else if d.Action == DiskInodeFillAction {
+		if err = d.validateInodeFill(); err != nil {
+			return err
+		}
+	} else if d.Action == DiskFillAction && d.FillByTarget() {
+		// the size is computed from the free space when the disk is filled
+		if err = d.validateFillTarget(); err != nil {
+			return err
+		}
+	} else if d.Watch || len(d.WatchInterval) > 0 {
+		return fmt.Errorf("watch is only supported by filling until used or leaving free, DiskOption : %v", d)
+	} else if d.Size == "" {
 		if d.Percent == "" {
 			return fmt.Errorf("one of percent and size must not be empty, DiskOption : %v", d)
 		}
@@ -55,20 +145,249 @@ func (d *DiskOption) Validate() error {
 			return fmt.Errorf("unknown units of size : %s, DiskOption : %v", d.Size, d)
 		}
 	}
-	if d.Action == DiskFillAction {
+	if d.Action == DiskFillAction && !d.FillByTarget() {
 		if d.FillByFallocate && byteSize == 0 {
 			return fmt.Errorf("fallocate not suppurt 0 size or 0 percent data, "+
 				"if you want allocate a 0 size file please set fallocate=false, DiskOption : %v", d)
 		}
 	}
 
-	if d.PayloadProcessNum == 0 {
+	if d.Action == DiskSustainedPayloadAction {
+		if err := d.validateSustainedPayload(); err != nil {
+			return err
+		}
+	}
+
+	// only the payloads split the size among the processes
+	if (d.Action == DiskWritePayloadAction || d.Action == DiskReadPayloadAction) && d.PayloadProcessNum == 0 {
 		return fmt.Errorf("unsupport process num : %d, DiskOption : %v", d.PayloadProcessNum, d.Action)
 	}
 
 	return nil
 }
 
+// ValidateOwnedFields returns an error if the fields which are only set by chaosd during the attack
+// are set by the request. They are kept in the recover data, so Validate accepts them.
+func (d DiskOption) ValidateOwnedFields() error {
+	if len(d.FillFile) > 0 || len(d.FillDir) > 0 || d.WatcherPid != 0 || d.PayloadPid != 0 || d.FaultPid != 0 {
+		return fmt.Errorf("fill file, fill dir, watcher, payload and fault process can only be set by chaosd, DiskOption : %v", d)
+	}
+	return nil
+}
+
+func (d *DiskOption) validateSustainedPayload() error {
+	if len(d.Size) == 0 {
+		return fmt.Errorf("size of the file to read and write must not be empty, DiskOption : %v", d)
+	}
+	if d.ReadPercent < 0 || d.ReadPercent > 100 {
+		return fmt.Errorf("unsupport read percent : %d, DiskOption : %v", d.ReadPercent, d)
+	}
+	if d.IOPS < 0 {
+		return fmt.Errorf("unsupport iops : %d, DiskOption : %v", d.IOPS, d)
+	}
+	if _, _, _, err := d.SustainedPayloadOptions(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SustainedPayloadOptions returns the sizes of the sustained payload in bytes.
+func (d DiskOption) SustainedPayloadOptions() (size int64, blockSize int64, rate int64, err error) {
+	sizeBytes, err := utils.ParseUnit(d.Size)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("unknown units of size : %s, DiskOption : %v", d.Size, d)
+	}
+
+	bs := d.BlockSize
+	if len(bs) == 0 {
+		bs = DefaultDiskPayloadBlockSize
+	}
+	blockBytes, err := utils.ParseUnit(bs)
+	if err != nil || blockBytes == 0 {
+		return 0, 0, 0, fmt.Errorf("unknown units of block size : %s, DiskOption : %v", bs, d)
+	}
+	if blockBytes > sizeBytes {
+		return 0, 0, 0, fmt.Errorf("block size %s is larger than size %s, DiskOption : %v", bs, d.Size, d)
+	}
+
+	var rateBytes uint64
+	if len(d.Rate) > 0 {
+		if rateBytes, err = utils.ParseUnit(d.Rate); err != nil || rateBytes == 0 {
+			return 0, 0, 0, fmt.Errorf("unknown units of rate : %s, DiskOption : %v", d.Rate, d)
+		}
+	}
+	return int64(sizeBytes), int64(blockBytes), int64(rateBytes), nil
+}
+
+func (d *DiskOption) validateFault() error {
+	if len(d.Path) == 0 {
+		return fmt.Errorf("path of the directory to inject faults must not be empty, DiskOption : %v", d)
+	}
+	if len(d.Latency) == 0 && len(d.Errno) == 0 {
+		return fmt.Errorf("one of latency and errno must not be empty, DiskOption : %v", d)
+	}
+	if _, err := d.FaultLatency(); err != nil {
+		return err
+	}
+	if _, err := d.FaultErrno(); err != nil {
+		return err
+	}
+	if _, err := d.FaultPercent(); err != nil {
+		return err
+	}
+	supported := make(map[string]bool)
+	for _, method := range DiskFaultMethods {
+		supported[method] = true
+	}
+	for _, method := range d.Methods {
+		if !supported[method] {
+			return fmt.Errorf("unsupport method : %s, only %s are supported, DiskOption : %v",
+				method, strings.Join(DiskFaultMethods, ", "), d)
+		}
+	}
+	return nil
+}
+
+func (d *DiskOption) validateInodeFill() error {
+	if len(d.UntilUsed) == 0 {
+		return fmt.Errorf("until used must not be empty, DiskOption : %v", d)
+	}
+	if len(d.Size) > 0 || len(d.Percent) > 0 || len(d.LeaveFree) > 0 {
+		return fmt.Errorf("only until used is supported by inode fill, DiskOption : %v", d)
+	}
+	if d.Watch || len(d.WatchInterval) > 0 {
+		return fmt.Errorf("watch is not supported by inode fill, DiskOption : %v", d)
+	}
+	if _, err := d.untilUsedPercent(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TargetFreeInodes returns the inodes which should be left free on the disk of the total inodes.
+func (d DiskOption) TargetFreeInodes(total uint64) (uint64, error) {
+	percent, err := d.untilUsedPercent()
+	if err != nil {
+		return 0, err
+	}
+	return total * (100 - percent) / 100, nil
+}
+
+// FaultLatency returns the latency of the faulted operations, 0 means no latency.
+func (d DiskOption) FaultLatency() (time.Duration, error) {
+	if len(d.Latency) == 0 {
+		return 0, nil
+	}
+	latency, err := time.ParseDuration(d.Latency)
+	if err != nil || latency < 0 {
+		return 0, fmt.Errorf("unsupport latency : %s, DiskOption : %v", d.Latency, d)
+	}
+	return latency, nil
+}
+
+// FaultErrno returns the errno of the faulted operations, 0 means the operations don't fail.
+func (d DiskOption) FaultErrno() (syscall.Errno, error) {
+	if len(d.Errno) == 0 {
+		return 0, nil
+	}
+	if errno, ok := diskFaultErrnos[strings.ToUpper(d.Errno)]; ok {
+		return errno, nil
+	}
+	errno, err := strconv.ParseUint(d.Errno, 10, 0)
+	if err != nil || errno == 0 {
+		return 0, fmt.Errorf("unsupport errno : %s, DiskOption : %v", d.Errno, d)
+	}
+	return syscall.Errno(errno), nil
+}
+
+// FaultPercent returns the percent of the operations which are faulted, all operations by default.
+func (d DiskOption) FaultPercent() (int, error) {
+	if len(d.Percent) == 0 {
+		return 100, nil
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(d.Percent), "%"))
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("unsupport percent : %s, DiskOption : %v", d.Percent, d)
+	}
+	return percent, nil
+}
+
+// FaultMountPoint returns where the FUSE file system of the fault action is mounted.
+func (d DiskOption) FaultMountPoint() string {
+	if len(d.MountPath) > 0 {
+		return d.MountPath
+	}
+	return d.Path
+}
+
+// FillByTarget returns whether the size to fill is computed from the target usage of the disk.
+func (d DiskOption) FillByTarget() bool {
+	return len(d.UntilUsed) > 0 || len(d.LeaveFree) > 0
+}
+
+func (d *DiskOption) validateFillTarget() error {
+	set := 0
+	for _, s := range []string{d.Size, d.Percent, d.UntilUsed, d.LeaveFree} {
+		if len(s) > 0 {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("only one of size, percent, until used and leave free can be set, DiskOption : %v", d)
+	}
+	if len(d.UntilUsed) > 0 {
+		if _, err := d.untilUsedPercent(); err != nil {
+			return err
+		}
+	}
+	if len(d.LeaveFree) > 0 {
+		if _, err := utils.ParseUnit(d.LeaveFree); err != nil {
+			return fmt.Errorf("unknown units of leave free : %s, DiskOption : %v", d.LeaveFree, d)
+		}
+	}
+	if d.Watch && d.DestroyFile {
+		return fmt.Errorf("the file to watch can't be destroyed, DiskOption : %v", d)
+	}
+	if len(d.WatchInterval) > 0 {
+		if interval, err := time.ParseDuration(d.WatchInterval); err != nil || interval <= 0 {
+			return fmt.Errorf("unsupport watch interval : %s, DiskOption : %v", d.WatchInterval, d)
+		}
+	}
+	return nil
+}
+
+func (d DiskOption) untilUsedPercent() (uint64, error) {
+	percent, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(d.UntilUsed), "%"), 10, 0)
+	if err != nil || percent > 100 {
+		return 0, fmt.Errorf("unsupport until used percent : %s, DiskOption : %v", d.UntilUsed, d)
+	}
+	return percent, nil
+}
+
+// TargetFreeSize returns the bytes which should be left free on the disk of the total bytes,
+// it's only valid if FillByTarget is true.
+func (d DiskOption) TargetFreeSize(total uint64) (uint64, error) {
+	if len(d.LeaveFree) > 0 {
+		return utils.ParseUnit(d.LeaveFree)
+	}
+
+	percent, err := d.untilUsedPercent()
+	if err != nil {
+		return 0, err
+	}
+	return total * (100 - percent) / 100, nil
+}
+
+// WatchIntervalDuration returns the interval to check the free space of the disk.
+func (d DiskOption) WatchIntervalDuration() time.Duration {
+	interval := d.WatchInterval
+	if len(interval) == 0 {
+		interval = DefaultDiskWatchInterval
+	}
+	duration, _ := time.ParseDuration(interval)
+	return duration
+}
+
 func (d DiskOption) RecoverData() string {
 	data, _ := json.Marshal(d)
 
@@ -0,0 +1,168 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiskFillTarget(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	d := NewDiskOption()
+	d.Action = DiskFillAction
+	d.PayloadProcessNum = 1
+	d.UntilUsed = "95%"
+	g.Expect(d.Validate()).Should(Succeed())
+	g.Expect(d.FillByTarget()).Should(BeTrue())
+	free, err := d.TargetFreeSize(1000)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(free).Should(Equal(uint64(50)))
+
+	d.UntilUsed = "101"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+
+	d.UntilUsed = ""
+	d.LeaveFree = "500MB"
+	d.Watch = true
+	g.Expect(d.Validate()).Should(Succeed())
+	free, err = d.TargetFreeSize(1000)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(free).Should(Equal(uint64(500 * 1000 * 1000)))
+	g.Expect(d.WatchIntervalDuration()).Should(Equal(10 * time.Second))
+
+	d.DestroyFile = true
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.DestroyFile = false
+
+	d.Size = "1G"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+
+	d.LeaveFree = ""
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+}
+
+func TestDiskSustainedPayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	d := NewDiskOption()
+	d.Action = DiskSustainedPayloadAction
+	d.Size = "1M"
+	d.Rate = "10MB"
+	g.Expect(d.Validate()).Should(Succeed())
+	size, blockSize, rate, err := d.SustainedPayloadOptions()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(size).Should(Equal(int64(1 << 20)))
+	g.Expect(blockSize).Should(Equal(int64(4096)))
+	g.Expect(rate).Should(Equal(int64(10 * 1000 * 1000)))
+
+	d.BlockSize = "2M"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.BlockSize = ""
+
+	d.ReadPercent = 101
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.ReadPercent = 100
+
+	d.Rate = "0"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.Rate = ""
+
+	// the process is kept in the recover data, but it can't be requested
+	d.PayloadPid = 1
+	g.Expect(d.Validate()).Should(Succeed())
+	g.Expect(d.ValidateOwnedFields()).ShouldNot(Succeed())
+	d.PayloadPid = 0
+
+	d.Size = ""
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+}
+
+func TestDiskFault(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	d := NewDiskOption()
+	d.Action = DiskFaultAction
+	d.Path = "/data"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+
+	d.Errno = "eio"
+	d.Methods = []string{"read", "write", "fsync"}
+	g.Expect(d.Validate()).Should(Succeed())
+	errno, err := d.FaultErrno()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(errno).Should(Equal(syscall.EIO))
+	percent, err := d.FaultPercent()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(percent).Should(Equal(100))
+	g.Expect(d.FaultMountPoint()).Should(Equal("/data"))
+
+	d.Errno = "28"
+	d.Latency = "200ms"
+	d.Percent = "10"
+	g.Expect(d.Validate()).Should(Succeed())
+	errno, _ = d.FaultErrno()
+	g.Expect(errno).Should(Equal(syscall.ENOSPC))
+
+	d.Percent = "0"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.Percent = ""
+
+	d.Latency = "-1s"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.Latency = ""
+
+	d.Methods = []string{"truncate"}
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.Methods = nil
+
+	// the process is kept in the recover data, but it can't be requested
+	d.FaultPid = 1
+	g.Expect(d.Validate()).Should(Succeed())
+	g.Expect(d.ValidateOwnedFields()).ShouldNot(Succeed())
+	d.FaultPid = 0
+
+	d.Path = ""
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+}
+
+func TestDiskInodeFill(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	d := NewDiskOption()
+	d.Action = DiskInodeFillAction
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+
+	d.UntilUsed = "95%"
+	g.Expect(d.Validate()).Should(Succeed())
+	free, err := d.TargetFreeInodes(1000)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(free).Should(Equal(uint64(50)))
+
+	d.Size = "1G"
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.Size = ""
+
+	d.Watch = true
+	g.Expect(d.Validate()).ShouldNot(Succeed())
+	d.Watch = false
+
+	d.FillDir = "/data/chaosd-inode-fill"
+	g.Expect(d.Validate()).Should(Succeed())
+	g.Expect(d.ValidateOwnedFields()).ShouldNot(Succeed())
+}
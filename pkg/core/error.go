@@ -19,4 +19,5 @@ var (
 	ErrNs                     = errorx.NewNamespace("error.core")
 	ErrAttackConfigValidation = ErrNs.NewType("attack_config_validation_error")
 	ErrNonRecoverableAttack   = ErrNs.NewType("non_recoverable_attack")
+	ErrAlreadyRecovered       = ErrNs.NewType("already_recovered")
 )
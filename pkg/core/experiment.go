@@ -25,14 +25,17 @@ const (
 	Scheduled = "scheduled"
 	Destroyed = "destroyed"
 	Revoked   = "revoked"
+	// Running is the status of an experiment run in progress
+	Running = "running"
 )
 
 const (
-	ProcessAttack = "process"
-	NetworkAttack = "network"
-	StressAttack  = "stress"
-	DiskAttack    = "disk"
-	HostAttack    = "host"
+	ProcessAttack   = "process"
+	NetworkAttack   = "network"
+	StressAttack    = "stress"
+	DiskAttack      = "disk"
+	HostAttack      = "host"
+	ContainerAttack = "container"
 )
 
 // ExperimentStore defines operations for working with experiments
@@ -59,4 +62,7 @@ type Experiment struct {
 	RecoverCommand string `json:"recover_command"`
 
 	Cron string `json:"cron"`
+	// RecoverAt is the time when the experiment will be recovered automatically,
+	// it is nil if the experiment has no duration.
+	RecoverAt *time.Time `json:"recover_at,omitempty"`
 }
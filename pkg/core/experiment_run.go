@@ -23,8 +23,8 @@ type ExperimentRunStore interface {
 	ListByExperimentID(ctx context.Context, id uint) ([]*ExperimentRun, error)
 	ListByExperimentUID(ctx context.Context, uid string) ([]*ExperimentRun, error)
 	LatestRun(ctx context.Context, id uint) (*ExperimentRun, error)
-
-	// TODO: experiment run creation and updation
+	Set(ctx context.Context, run *ExperimentRun) error
+	Update(ctx context.Context, uid, status, msg string, finishedAt time.Time) error
 }
 
 // ExperimentRun represents a run of an experiment
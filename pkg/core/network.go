@@ -24,6 +24,7 @@ import (
 
 	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
 
+	"github.com/chaos-mesh/chaosd/pkg/container"
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
@@ -40,6 +41,24 @@ type NetworkCommand struct {
 	IPAddress   string
 	IPProtocol  string
 	Hostname    string
+	// ContainerID is the container in whose network namespace the attack is applied,
+	// such as docker://xxx, containerd://xxx or cri-o://xxx. The attack is applied on the host if it is empty.
+	ContainerID string `json:"container_id,omitempty"`
+
+	// used for delay attack to reorder packets
+	Reorder            string
+	ReorderCorrelation string
+	Gap                int
+	// used for partition attack, it is "to", "from" or "both".
+	// used for the other tc attacks, it is "egress" or "ingress".
+	Direction string
+
+	// used for bandwidth attack
+	Rate     string
+	Limit    uint32
+	Buffer   uint32
+	Peakrate uint64
+	Minburst uint32
 
 	// used for DNS attack
 	DNSServer string
@@ -55,9 +74,34 @@ const (
 	NetworkCorruptAction   = "corrupt"
 	NetworkDuplicateAction = "duplicate"
 	NetworkDNSAction       = "dns"
+	NetworkPartitionAction = "partition"
+	NetworkBandwidthAction = "bandwidth"
+)
+
+const (
+	// DirectionTo blocks the traffic sent to the target
+	DirectionTo = "to"
+	// DirectionFrom blocks the traffic received from the target
+	DirectionFrom = "from"
+	// DirectionBoth blocks the traffic in both directions
+	DirectionBoth = "both"
+
+	// DirectionEgress impacts the traffic sent by the device
+	DirectionEgress = "egress"
+	// DirectionIngress impacts the traffic received by the device
+	DirectionIngress = "ingress"
 )
 
 func (n NetworkCommand) Validate() error {
+	if len(n.ContainerID) > 0 {
+		if n.Action == NetworkDNSAction {
+			return errors.New("container id is not supported by dns attack")
+		}
+		if _, err := container.RuntimeOf(n.ContainerID); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	switch n.Action {
 	case NetworkDelayAction:
 		return n.validNetworkDelay()
@@ -65,6 +109,10 @@ func (n NetworkCommand) Validate() error {
 		return n.validNetworkCommon()
 	case NetworkDNSAction:
 		return n.validNetworkDNS()
+	case NetworkPartitionAction:
+		return n.validNetworkPartition()
+	case NetworkBandwidthAction:
+		return n.validNetworkBandwidth()
 	default:
 		return errors.Errorf("network action %s not supported", n.Action)
 	}
@@ -89,6 +137,10 @@ func (n *NetworkCommand) validNetworkDelay() error {
 		return errors.Errorf("correlation %s not valid", n.Correlation)
 	}
 
+	if err := n.validNetworkReorder(); err != nil {
+		return err
+	}
+
 	if len(n.Device) == 0 {
 		return errors.New("device is required")
 	}
@@ -97,9 +149,59 @@ func (n *NetworkCommand) validNetworkDelay() error {
 		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
 	}
 
+	if err := n.validTCDirection(); err != nil {
+		return err
+	}
+
 	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
 }
 
+func (n *NetworkCommand) validTCDirection() error {
+	switch n.Direction {
+	case "", DirectionEgress:
+		return nil
+	case DirectionIngress:
+		// the ingress traffic is redirected to an IFB device, which bypasses
+		// the iptables rules used to filter the traffic.
+		if n.NeedApplyIPSet() || len(n.IPProtocol) > 0 || len(n.SourcePort) > 0 || len(n.EgressPort) > 0 {
+			return errors.New("ip address, hostname, protocol and ports are not supported for ingress traffic")
+		}
+		return nil
+	default:
+		return errors.Errorf("direction %s not supported", n.Direction)
+	}
+}
+
+func (n *NetworkCommand) validNetworkReorder() error {
+	if len(n.Reorder) == 0 {
+		if len(n.ReorderCorrelation) > 0 || n.Gap != 0 {
+			return errors.New("reorder is required")
+		}
+		return nil
+	}
+
+	if !utils.CheckPercent(n.Reorder) {
+		return errors.Errorf("reorder %s not valid", n.Reorder)
+	}
+
+	if !utils.CheckPercent(n.ReorderCorrelation) {
+		return errors.Errorf("reorder correlation %s not valid", n.ReorderCorrelation)
+	}
+
+	if n.Gap < 0 {
+		return errors.Errorf("gap %d not valid", n.Gap)
+	}
+
+	// netem sends the reordered packets immediately and delays the others,
+	// so the packets can't be reordered without delay.
+	latency, _ := time.ParseDuration(n.Latency)
+	if latency <= 0 {
+		return errors.New("reorder requires a delay greater than 0")
+	}
+
+	return nil
+}
+
 func (n *NetworkCommand) validNetworkCommon() error {
 	if len(n.Percent) == 0 {
 		return errors.New("percent is required")
@@ -121,6 +223,10 @@ func (n *NetworkCommand) validNetworkCommon() error {
 		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
 	}
 
+	if err := n.validTCDirection(); err != nil {
+		return err
+	}
+
 	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
 }
 
@@ -140,6 +246,60 @@ func (n *NetworkCommand) validNetworkDNS() error {
 	return nil
 }
 
+func (n *NetworkCommand) validNetworkPartition() error {
+	switch n.Direction {
+	case DirectionTo, DirectionFrom, DirectionBoth:
+	default:
+		return errors.Errorf("direction %s not supported", n.Direction)
+	}
+
+	if !n.NeedApplyIPSet() {
+		return errors.New("ip address or hostname is required")
+	}
+
+	if !utils.CheckIPs(n.IPAddress) {
+		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
+	}
+
+	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
+}
+
+func (n *NetworkCommand) validNetworkBandwidth() error {
+	if len(n.Rate) == 0 {
+		return errors.New("rate is required")
+	}
+
+	if _, err := convertUnitToBytes(n.Rate); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("rate %s not valid", n.Rate))
+	}
+
+	if n.Limit == 0 {
+		return errors.New("limit is required")
+	}
+
+	if n.Buffer == 0 {
+		return errors.New("buffer is required")
+	}
+
+	if (n.Peakrate == 0) != (n.Minburst == 0) {
+		return errors.New("peakrate and minburst must be set together")
+	}
+
+	if len(n.Device) == 0 {
+		return errors.New("device is required")
+	}
+
+	if !utils.CheckIPs(n.IPAddress) {
+		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
+	}
+
+	if err := n.validTCDirection(); err != nil {
+		return err
+	}
+
+	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
+}
+
 func (n *NetworkCommand) CompleteDefaults() {
 	switch n.Action {
 	case NetworkDelayAction:
@@ -148,6 +308,8 @@ func (n *NetworkCommand) CompleteDefaults() {
 		n.setDefaultForNetworkLoss()
 	case NetworkDNSAction:
 		n.setDefaultForNetworkDNS()
+	case NetworkPartitionAction:
+		n.setDefaultForNetworkPartition()
 	}
 }
 
@@ -159,6 +321,10 @@ func (n *NetworkCommand) setDefaultForNetworkDelay() {
 	if len(n.Correlation) == 0 {
 		n.Correlation = "0"
 	}
+
+	if len(n.Reorder) > 0 && len(n.ReorderCorrelation) == 0 {
+		n.ReorderCorrelation = "0"
+	}
 }
 
 func (n *NetworkCommand) setDefaultForNetworkLoss() {
@@ -173,6 +339,12 @@ func (n *NetworkCommand) setDefaultForNetworkDNS() {
 	}
 }
 
+func (n *NetworkCommand) setDefaultForNetworkPartition() {
+	if len(n.Direction) == 0 {
+		n.Direction = DirectionBoth
+	}
+}
+
 func checkProtocolAndPorts(p string, sports string, dports string) error {
 	if !utils.CheckPorts(sports) {
 		return errors.Errorf("source ports %s not valid", sports)
@@ -204,28 +376,31 @@ func (n NetworkCommand) RecoverData() string {
 }
 
 func (n *NetworkCommand) ToDelayNetem() (*pb.Netem, error) {
-	delayTime, err := time.ParseDuration(n.Latency)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
+	return n.ToDelaySpec().ToNetem()
+}
 
-	jitter, err := time.ParseDuration(n.Jitter)
-	if err != nil {
-		return nil, errors.WithStack(err)
+// ToDelaySpec returns the delay spec of the delay attack.
+func (n *NetworkCommand) ToDelaySpec() *DelaySpec {
+	return &DelaySpec{
+		Latency:     n.Latency,
+		Correlation: n.Correlation,
+		Jitter:      n.Jitter,
+		Reorder:     n.ToReorderSpec(),
 	}
+}
 
-	corr, err := strconv.ParseFloat(n.Correlation, 32)
-	if err != nil {
-		return nil, errors.WithStack(err)
+// ToReorderSpec returns the reorder spec of the delay attack,
+// it returns nil if the packets are not reordered.
+func (n *NetworkCommand) ToReorderSpec() *ReorderSpec {
+	if len(n.Reorder) == 0 {
+		return nil
 	}
 
-	netem := &pb.Netem{
-		Time:      uint32(delayTime.Nanoseconds() / 1e3),
-		DelayCorr: float32(corr),
-		Jitter:    uint32(jitter.Nanoseconds() / 1e3),
+	return &ReorderSpec{
+		Reorder:     n.Reorder,
+		Correlation: n.ReorderCorrelation,
+		Gap:         n.Gap,
 	}
-
-	return netem, nil
 }
 
 func (n *NetworkCommand) ToLossNetem() (*pb.Netem, error) {
@@ -277,6 +452,23 @@ func (n *NetworkCommand) ToDuplicateNetem() (*pb.Netem, error) {
 	}, nil
 }
 
+// ToBandwidthSpec returns the bandwidth spec of the bandwidth attack.
+func (n *NetworkCommand) ToBandwidthSpec() *BandwidthSpec {
+	spec := &BandwidthSpec{
+		Rate:   n.Rate,
+		Limit:  n.Limit,
+		Buffer: n.Buffer,
+	}
+
+	if n.Peakrate > 0 && n.Minburst > 0 {
+		peakrate, minburst := n.Peakrate, n.Minburst
+		spec.Peakrate = &peakrate
+		spec.Minburst = &minburst
+	}
+
+	return spec
+}
+
 func (n *NetworkCommand) ToTC(ipset string) (*pb.Tc, error) {
 	tc := &pb.Tc{
 		Type:       pb.Tc_NETEM,
@@ -307,6 +499,16 @@ func (n *NetworkCommand) ToTC(ipset string) (*pb.Tc, error) {
 		if netem, err = n.ToDuplicateNetem(); err != nil {
 			return nil, errors.WithStack(err)
 		}
+	case NetworkBandwidthAction:
+		tbf, err := n.ToBandwidthSpec().ToTbf()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		tc.Type = pb.Tc_BANDWIDTH
+		tc.Tbf = tbf
+
+		return tc, nil
 	default:
 		return nil, errors.Errorf("action %s not supported", n.Action)
 	}
@@ -342,6 +544,16 @@ func (n *NetworkCommand) ToIPSet(name string) (*pb.IPSet, error) {
 	}, nil
 }
 
+// Ingress returns true if the tc attack impacts the ingress traffic of the device.
+func (n *NetworkCommand) Ingress() bool {
+	return n.NeedApplyTC() && n.Direction == DirectionIngress
+}
+
+// EnterNS returns true if the attack is applied in the network namespace of a container.
+func (n *NetworkCommand) EnterNS() bool {
+	return len(n.ContainerID) > 0
+}
+
 func (n *NetworkCommand) NeedApplyIPSet() bool {
 	if len(n.IPAddress) > 0 || len(n.Hostname) > 0 {
 		return true
@@ -351,12 +563,12 @@ func (n *NetworkCommand) NeedApplyIPSet() bool {
 }
 
 func (n *NetworkCommand) NeedApplyIptables() bool {
-	return true
+	return n.NeedApplyTC() || n.Action == NetworkPartitionAction
 }
 
 func (n *NetworkCommand) NeedApplyTC() bool {
 	switch n.Action {
-	case NetworkDelayAction, NetworkLossAction, NetworkCorruptAction, NetworkDuplicateAction:
+	case NetworkDelayAction, NetworkLossAction, NetworkCorruptAction, NetworkDuplicateAction, NetworkBandwidthAction:
 		return true
 	default:
 		return false
@@ -375,8 +587,60 @@ func (n *NetworkCommand) NeedApplyDNSServer() bool {
 	return len(n.DNSServer) > 0
 }
 
-func (n *NetworkCommand) ToChain() (*pb.Chain, error) {
-	return nil, nil
+// ToIptablesRules returns the iptables rules of the partition attack,
+// it returns nil for the other actions.
+func (n *NetworkCommand) ToIptablesRules(ipset string, uid string) ([]*IptablesRule, error) {
+	if n.Action != NetworkPartitionAction {
+		return nil, nil
+	}
+
+	var directions []pb.Chain_Direction
+	switch n.Direction {
+	case DirectionTo:
+		directions = []pb.Chain_Direction{pb.Chain_OUTPUT}
+	case DirectionFrom:
+		directions = []pb.Chain_Direction{pb.Chain_INPUT}
+	case DirectionBoth:
+		directions = []pb.Chain_Direction{pb.Chain_INPUT, pb.Chain_OUTPUT}
+	default:
+		return nil, errors.Errorf("direction %s not supported", n.Direction)
+	}
+
+	rules := make([]*IptablesRule, 0, len(directions))
+	for _, direction := range directions {
+		rules = append(rules, &IptablesRule{
+			Name:        chainName(direction, uid),
+			IPSets:      ipset,
+			Direction:   pb.Chain_Direction_name[int32(direction)],
+			Protocol:    n.IPProtocol,
+			SourcePort:  n.SourcePort,
+			EgressPort:  n.EgressPort,
+			Experiment:  uid,
+			ContainerID: n.ContainerID,
+		})
+	}
+
+	return rules, nil
+}
+
+const (
+	inputChainPrefix  = "chaos-in-"
+	outputChainPrefix = "chaos-out-"
+)
+
+// chainName returns the name of the iptables chain, which is limited to 28 characters.
+func chainName(direction pb.Chain_Direction, uid string) string {
+	prefix := inputChainPrefix
+	if direction == pb.Chain_OUTPUT {
+		prefix = outputChainPrefix
+	}
+
+	return prefix + uid[:16]
+}
+
+// IsPartitionChain returns true if the iptables chain is created by the partition attack.
+func IsPartitionChain(name string) bool {
+	return strings.HasPrefix(name, inputChainPrefix) || strings.HasPrefix(name, outputChainPrefix)
 }
 
 func NewNetworkCommand() *NetworkCommand {
@@ -16,6 +16,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
@@ -43,6 +44,9 @@ type IPSetRule struct {
 	Cidrs string `json:"cidrs"`
 	// Experiment represents the experiment which the rule belong to.
 	Experiment string `gorm:"index:experiment" json:"experiment"`
+	// ContainerID represents the container in whose network namespace the rule is applied,
+	// it is empty for the rules applied on the host.
+	ContainerID string `gorm:"index:container_id" json:"container_id,omitempty"`
 }
 
 type Cidr struct {
@@ -57,6 +61,7 @@ type IptablesRuleStore interface {
 	List(ctx context.Context) ([]*IptablesRule, error)
 	Set(ctx context.Context, rule *IptablesRule) error
 	FindByExperiment(ctx context.Context, experiment string) ([]*IptablesRule, error)
+	FindByContainer(ctx context.Context, containerID string) ([]*IptablesRule, error)
 	DeleteByExperiment(ctx context.Context, experiment string) error
 }
 
@@ -70,6 +75,13 @@ type IptablesRule struct {
 	Direction string `json:"direction"`
 	// Experiment represents the experiment which the rule belong to.
 	Experiment string `gorm:"index:experiment" json:"experiment"`
+	// ContainerID represents the container in whose network namespace the rule is applied,
+	// it is empty for the rules applied on the host.
+	ContainerID string `gorm:"index:container_id" json:"container_id,omitempty"`
+
+	Protocol   string `json:"protocol,omitempty"`
+	SourcePort string `json:"source_port,omitempty"`
+	EgressPort string `json:"egress_port,omitempty"`
 }
 
 func (i *IptablesRule) ToChain() *pb.Chain {
@@ -80,6 +92,24 @@ func (i *IptablesRule) ToChain() *pb.Chain {
 		Target:    "DROP",
 	}
 
+	if len(i.Protocol) > 0 {
+		ch.Protocol = fmt.Sprintf("--protocol %s", i.Protocol)
+	}
+
+	if len(i.SourcePort) > 0 {
+		ch.SourcePorts = fmt.Sprintf("--source-port %s", i.SourcePort)
+		if strings.Contains(i.SourcePort, ",") {
+			ch.SourcePorts = fmt.Sprintf("-m multiport --source-ports %s", i.SourcePort)
+		}
+	}
+
+	if len(i.EgressPort) > 0 {
+		ch.DestinationPorts = fmt.Sprintf("--destination-port %s", i.EgressPort)
+		if strings.Contains(i.EgressPort, ",") {
+			ch.DestinationPorts = fmt.Sprintf("-m multiport --destination-ports %s", i.EgressPort)
+		}
+	}
+
 	return ch
 }
 
@@ -97,9 +127,9 @@ func (l IptablesRuleList) ToChains() []*pb.Chain {
 
 type TCRuleStore interface {
 	List(ctx context.Context) ([]*TCRule, error)
-	ListGroupDevice(ctx context.Context) (map[string][]*TCRule, error)
+	ListGroupDevice(ctx context.Context, containerID string) (map[string][]*TCRule, error)
 	Set(ctx context.Context, rule *TCRule) error
-	FindByDevice(ctx context.Context, experiment string) ([]*TCRule, error)
+	FindByDevice(ctx context.Context, containerID string, device string) ([]*TCRule, error)
 	FindByExperiment(ctx context.Context, experiment string) ([]*TCRule, error)
 	DeleteByExperiment(ctx context.Context, experiment string) error
 }
@@ -114,10 +144,17 @@ type TCRule struct {
 	IPSet string `json:"ipset,omitempty"`
 	// Experiment represents the experiment which the rule belong to.
 	Experiment string `gorm:"index:experiment" json:"experiment"`
+	// ContainerID represents the container in whose network namespace the rule is applied,
+	// it is empty for the rules applied on the host.
+	ContainerID string `gorm:"index:container_id" json:"container_id,omitempty"`
 
 	Protocal   string
 	SourcePort string
 	EgressPort string
+
+	// IngressDevice is the device whose ingress traffic is redirected to the IFB device,
+	// it is empty if the rule impacts the egress traffic of the device.
+	IngressDevice string `json:"ingress_device,omitempty"`
 }
 
 func (t *TCRule) ToTC() (*pb.Tc, error) {
@@ -0,0 +1,198 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
+)
+
+func TestNetworkPartitionToIptablesRules(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	uid := "93e7ae10-0c13-4db7-9ba7-bfe18d07d764"
+	n := NewNetworkCommand()
+	n.Action = NetworkPartitionAction
+	n.IPAddress = "1.2.3.4"
+	n.CompleteDefaults()
+	g.Expect(n.Validate()).Should(Succeed())
+
+	rules, err := n.ToIptablesRules("chaos-93e7ae10-0c13-4d", uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(rules).Should(HaveLen(2))
+
+	chains := IptablesRuleList(rules).ToChains()
+	g.Expect(chains[0]).Should(Equal(&pb.Chain{
+		Name:      "chaos-in-93e7ae10-0c13-4d",
+		Direction: pb.Chain_INPUT,
+		Ipsets:    []string{"chaos-93e7ae10-0c13-4d"},
+		Target:    "DROP",
+	}))
+	g.Expect(chains[1].Name).Should(Equal("chaos-out-93e7ae10-0c13-4d"))
+	g.Expect(chains[1].Direction).Should(Equal(pb.Chain_OUTPUT))
+
+	n.Direction = DirectionTo
+	n.IPProtocol = "tcp"
+	n.EgressPort = "80,443"
+	rules, err = n.ToIptablesRules("chaos-93e7ae10-0c13-4d", uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(rules).Should(HaveLen(1))
+
+	chain := rules[0].ToChain()
+	g.Expect(chain.Direction).Should(Equal(pb.Chain_OUTPUT))
+	g.Expect(chain.Protocol).Should(Equal("--protocol tcp"))
+	g.Expect(chain.SourcePorts).Should(BeEmpty())
+	g.Expect(chain.DestinationPorts).Should(Equal("-m multiport --destination-ports 80,443"))
+
+	n.Action = NetworkDelayAction
+	rules, err = n.ToIptablesRules("chaos-93e7ae10-0c13-4d", uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(rules).Should(BeEmpty())
+}
+
+func TestNetworkPartitionValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	n := NewNetworkCommand()
+	n.Action = NetworkPartitionAction
+	n.CompleteDefaults()
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+
+	n.Hostname = "example.com"
+	n.Direction = "up"
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+
+	n.Direction = DirectionFrom
+	g.Expect(n.Validate()).Should(Succeed())
+
+	n.SourcePort = "8080"
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+}
+
+func TestNetworkBandwidthToTC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	n := NewNetworkCommand()
+	n.Action = NetworkBandwidthAction
+	n.Device = "eth0"
+	n.Rate = "1mbps"
+	n.Limit = 100
+	n.Buffer = 10000
+	g.Expect(n.Validate()).Should(Succeed())
+
+	tc, err := n.ToTC("")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(tc.Type).Should(Equal(pb.Tc_BANDWIDTH))
+	g.Expect(tc.Tbf).Should(Equal(&pb.Tbf{Rate: 1024 * 1024, Limit: 100, Buffer: 10000}))
+
+	// the stored rule is converted back to the same tc
+	param, err := json.Marshal(&TcParameter{Device: n.Device, Bandwidth: n.ToBandwidthSpec()})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	rule := &TCRule{Device: n.Device, Type: pb.Tc_BANDWIDTH.String(), TC: string(param)}
+	restored, err := rule.ToTC()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(restored).Should(Equal(tc))
+
+	n.Peakrate = 2048
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+	n.Minburst = 1500
+	g.Expect(n.Validate()).Should(Succeed())
+	tc, err = n.ToTC("")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(tc.Tbf.PeakRate).Should(Equal(uint64(2048)))
+	g.Expect(tc.Tbf.MinBurst).Should(Equal(uint32(1500)))
+}
+
+func TestNetworkDelayReorder(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	n := NewNetworkCommand()
+	n.Action = NetworkDelayAction
+	n.Device = "eth0"
+	n.Latency = "10ms"
+	n.Reorder = "25"
+	n.Gap = 5
+	n.CompleteDefaults()
+	g.Expect(n.Validate()).Should(Succeed())
+
+	netem, err := n.ToDelayNetem()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(netem.Time).Should(Equal(uint32(10000)))
+	g.Expect(netem.Reorder).Should(Equal(float32(25)))
+	g.Expect(netem.ReorderCorr).Should(Equal(float32(0)))
+	g.Expect(netem.Gap).Should(Equal(uint32(5)))
+
+	n.Latency = "0ms"
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+
+	n.Latency = "10ms"
+	n.Reorder = ""
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+}
+
+func TestNetworkContainerID(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	n := NewNetworkCommand()
+	n.Action = NetworkPartitionAction
+	n.IPAddress = "1.2.3.4"
+	n.ContainerID = "docker://a1b2c3"
+	n.CompleteDefaults()
+	g.Expect(n.Validate()).Should(Succeed())
+	g.Expect(n.EnterNS()).Should(BeTrue())
+
+	rules, err := n.ToIptablesRules("chaos-93e7ae10-0c13-4d", "93e7ae10-0c13-4db7-9ba7-bfe18d07d764")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(rules[0].ContainerID).Should(Equal(n.ContainerID))
+
+	// the container is requested with the same key as the other attacks
+	var requested NetworkCommand
+	g.Expect(json.Unmarshal([]byte(`{"action":"partition","container_id":"docker://a1b2c3"}`), &requested)).Should(Succeed())
+	g.Expect(requested.ContainerID).Should(Equal(n.ContainerID))
+
+	n.ContainerID = "a1b2c3"
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+
+	n.Action = NetworkDNSAction
+	n.ContainerID = "containerd://a1b2c3"
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+}
+
+func TestNetworkIngress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	n := NewNetworkCommand()
+	n.Action = NetworkLossAction
+	n.Device = "eth0"
+	n.Percent = "50"
+	n.Direction = DirectionIngress
+	n.CompleteDefaults()
+	g.Expect(n.Validate()).Should(Succeed())
+	g.Expect(n.Ingress()).Should(BeTrue())
+
+	n.IPAddress = "1.2.3.4"
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+
+	n.IPAddress = ""
+	n.Direction = DirectionBoth
+	g.Expect(n.Validate()).ShouldNot(Succeed())
+
+	n.Direction = ""
+	g.Expect(n.Validate()).Should(Succeed())
+	g.Expect(n.Ingress()).Should(BeFalse())
+}
@@ -15,13 +15,22 @@ package core
 
 import (
 	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/pingcap/errors"
+	"golang.org/x/sys/unix"
 )
 
 const (
 	ProcessKillAction = "kill"
 	ProcessStopAction = "stop"
+	ProcessFlapAction = "flap"
+
+	DefaultRestartTimeout = time.Minute
 )
 
 var _ AttackConfig = &ProcessCommand{}
@@ -31,26 +40,142 @@ type ProcessCommand struct {
 
 	// Process defines the process name or the process ID.
 	Process string
-	Signal  int
-	PIDs    []int
-	// TODO: support these feature
-	// Newest       bool
-	// Oldest       bool
-	// Exact        bool
-	// KillChildren bool
-	// User         string
+	// Regex means Process is a regular expression matched against the process name,
+	// or against the command line if FullCmdline is set.
+	Regex bool
+	// FullCmdline means Process is matched against the full command line
+	// read from /proc/<pid>/cmdline instead of the process name.
+	FullCmdline bool
+	// User defines the user name or the UID owning the processes.
+	User string
+	// Newest selects only the most recently started process of the matches.
+	Newest bool
+	// Oldest selects only the least recently started process of the matches.
+	Oldest bool
+	// MaxCount limits the count of the matched processes, 0 means no limit.
+	MaxCount int
+	// KillChildren sends the signal to the descendants of the matched processes as well.
+	KillChildren bool
+
+	// Signal is the number of the signal sent to the processes,
+	// only the attack with SIGSTOP can be recovered.
+	Signal int
+	// PIDs are the processes signaled by the attack, in the order the signal is sent.
+	PIDs []int
+	// StartTimes are the create times of the PIDs in milliseconds since the epoch,
+	// the PIDs reused by other processes are not signaled on recovery.
+	StartTimes []int64
+
+	// Times is the count of the kills of the flap action, 0 means no limit.
+	Times int
+	// RestartTimeout is the max time the flap action waits for the killed processes to be restarted.
+	RestartTimeout string
 }
 
 func (p ProcessCommand) Validate() error {
+	if len(p.Process) == 0 && len(p.User) == 0 {
+		return errors.New("process or user not provided")
+	}
+
+	if p.Regex {
+		if _, err := regexp.Compile(p.Process); err != nil {
+			return errors.Wrapf(err, "process regex %s not valid", p.Process)
+		}
+	}
+
+	if p.Newest && p.Oldest {
+		return errors.New("newest and oldest can't be set at the same time")
+	}
+
+	if p.MaxCount < 0 {
+		return errors.Errorf("max count %d must not be negative", p.MaxCount)
+	}
+
+	if len(unix.SignalName(syscall.Signal(p.Signal))) == 0 {
+		return errors.Errorf("signal %d is not supported", p.Signal)
+	}
+
+	if p.Action == ProcessStopAction && p.Signal != int(syscall.SIGSTOP) {
+		return errors.Errorf("stop action only sends SIGSTOP, but got %s", unix.SignalName(syscall.Signal(p.Signal)))
+	}
+
+	if p.Action == ProcessFlapAction {
+		return p.validFlap()
+	}
+
+	return nil
+}
+
+func (p ProcessCommand) validFlap() error {
 	if len(p.Process) == 0 {
-		return errors.New("process not provided")
+		return errors.New("process not provided, it is required to find the restarted processes")
 	}
 
-	// TODO: validate signal
+	if p.Signal == int(syscall.SIGSTOP) {
+		return errors.New("flap action can't stop the processes, they are never restarted")
+	}
+
+	if len(p.Cron()) > 0 {
+		return errors.New("flap action can't be scheduled, it repeats the kills by itself")
+	}
+
+	if p.Times < 0 {
+		return errors.Errorf("times %d must not be negative", p.Times)
+	}
+
+	if p.Times == 0 && len(p.Duration) == 0 {
+		return errors.New("times or duration must be provided to end the flap action")
+	}
+
+	if _, err := p.RestartTimeoutDuration(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// RestartTimeoutDuration returns the parsed RestartTimeout, or DefaultRestartTimeout if it is empty.
+func (p ProcessCommand) RestartTimeoutDuration() (time.Duration, error) {
+	if len(p.RestartTimeout) == 0 {
+		return DefaultRestartTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(p.RestartTimeout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "restart timeout %s not valid", p.RestartTimeout)
+	}
+	if timeout <= 0 {
+		return 0, errors.Errorf("restart timeout %s must be positive", p.RestartTimeout)
+	}
+	return timeout, nil
+}
+
+// Recoverable returns true if the processes can be recovered after receiving the signal.
+func (p ProcessCommand) Recoverable() bool {
+	return p.Signal == int(syscall.SIGSTOP)
+}
+
+// ParseSignal parses the signal from its number, or its name with or without the SIG prefix,
+// such as "9", "SIGKILL", "KILL" or "kill".
+func ParseSignal(signal string) (int, error) {
+	if num, err := strconv.Atoi(signal); err == nil {
+		if len(unix.SignalName(syscall.Signal(num))) == 0 {
+			return 0, errors.Errorf("signal %d is not supported", num)
+		}
+		return num, nil
+	}
+
+	name := strings.ToUpper(signal)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	num := unix.SignalNum(name)
+	if num == 0 {
+		return 0, errors.Errorf("signal %s is not supported", signal)
+	}
+	return int(num), nil
+}
+
 func (p ProcessCommand) RecoverData() string {
 	data, _ := json.Marshal(p)
 
@@ -0,0 +1,82 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"syscall"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseSignal(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, signal := range []string{"1", "SIGHUP", "HUP", "hup"} {
+		num, err := ParseSignal(signal)
+		g.Expect(err).ShouldNot(HaveOccurred())
+		g.Expect(num).Should(Equal(int(syscall.SIGHUP)))
+	}
+
+	num, err := ParseSignal("sigusr1")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(num).Should(Equal(int(syscall.SIGUSR1)))
+
+	for _, signal := range []string{"0", "-9", "1000", "SIGFOO", ""} {
+		_, err = ParseSignal(signal)
+		g.Expect(err).Should(HaveOccurred())
+	}
+}
+
+func TestProcessSignalValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := NewProcessCommand()
+	p.Action = ProcessKillAction
+	p.Process = "nginx"
+	p.Signal = int(syscall.SIGSEGV)
+	g.Expect(p.Validate()).Should(Succeed())
+	g.Expect(p.Recoverable()).Should(BeFalse())
+
+	p.Signal = 0
+	g.Expect(p.Validate()).ShouldNot(Succeed())
+
+	p.Action = ProcessStopAction
+	p.Signal = int(syscall.SIGTERM)
+	g.Expect(p.Validate()).ShouldNot(Succeed())
+
+	p.Signal = int(syscall.SIGSTOP)
+	g.Expect(p.Validate()).Should(Succeed())
+	g.Expect(p.Recoverable()).Should(BeTrue())
+}
+
+func TestProcessFlapValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := NewProcessCommand()
+	p.Action = ProcessFlapAction
+	p.Process = "nginx"
+	p.Signal = int(syscall.SIGKILL)
+	g.Expect(p.Validate()).ShouldNot(Succeed())
+
+	p.Times = 3
+	g.Expect(p.Validate()).Should(Succeed())
+
+	p.RestartTimeout = "0s"
+	g.Expect(p.Validate()).ShouldNot(Succeed())
+
+	p.RestartTimeout = ""
+	p.Schedule = "@every 1m"
+	g.Expect(p.Validate()).ShouldNot(Succeed())
+}
@@ -25,6 +25,8 @@ type SearchCommand struct {
 	Limit  uint32
 	Offset uint32
 	UID    string
+	// Runs lists the runs of the experiment UID instead of the experiment
+	Runs bool
 }
 
 func (s *SearchCommand) Validate() error {
@@ -32,9 +34,13 @@ func (s *SearchCommand) Validate() error {
 		return nil
 	}
 
+	if s.Runs {
+		return errors.New("UID is required to search runs")
+	}
+
 	if len(s.Kind) > 0 {
 		switch s.Kind {
-		case NetworkAttack, ProcessAttack:
+		case NetworkAttack, ProcessAttack, ContainerAttack:
 			break
 		default:
 			return errors.Errorf("type %s not supported", s.Kind)
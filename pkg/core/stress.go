@@ -15,16 +15,29 @@ package core
 
 import (
 	"encoding/json"
-	"time"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/container"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
 const (
-	StressCPUAction = "cpu"
-	StressMemAction = "mem"
+	StressCPUAction    = "cpu"
+	StressMemAction    = "mem"
+	StressIOAction     = "io"
+	StressHDDAction    = "hdd"
+	StressForkAction   = "fork"
+	StressSwitchAction = "switch"
+	StressCacheAction  = "cache"
+	StressCustomAction = "custom"
 )
 
+var stressorNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
 type StressCommand struct {
 	CommonAttackConfig
 
@@ -32,8 +45,30 @@ type StressCommand struct {
 	Workers     int
 	Size        string
 	Options     []string
-	Duration    time.Duration
 	StressngPid int32
+
+	// StressngStartTime is the create time of the stress-ng process in milliseconds since the epoch,
+	// which tells whether the pid is reused by another process.
+	StressngStartTime int64 `json:"stressng_start_time,omitempty"`
+	// StressngPgid is the process group of stress-ng and all its workers.
+	StressngPgid int32 `json:"stressng_pgid,omitempty"`
+
+	// HDDBytes specifies the bytes written per hdd worker, as % of the free space
+	// or in units of B, KB/KiB, MB/MiB, GB/GiB...
+	HDDBytes string `json:"hdd_bytes,omitempty"`
+	// Stressors are the stressors of the custom action, formatted as <name>[:<workers>],
+	// such as matrix:2. The workers default to 1, and 0 means one worker per CPU.
+	Stressors []string `json:"stressors,omitempty"`
+	// Profile changes the load of the cpu and mem actions over time, see LoadSegment for the format.
+	// The load of mem is the percent of the total memory.
+	Profile []string `json:"profile,omitempty"`
+
+	// ContainerID confines the stress-ng process to the cgroups of the container,
+	// such as docker://xxx, containerd://xxx or cri-o://xxx.
+	ContainerID string `json:"container_id,omitempty"`
+	// CGroup confines the stress-ng process to the cgroup path, such as /kubepods/pod1/xxx,
+	// in all mounted cgroup hierarchies which have this cgroup.
+	CGroup string `json:"cgroup,omitempty"`
 }
 
 var _ AttackConfig = &StressCommand{}
@@ -43,9 +78,121 @@ func (s StressCommand) Validate() error {
 		return errors.New("action not provided")
 	}
 
+	switch s.Action {
+	case StressCPUAction, StressMemAction:
+	case StressIOAction, StressForkAction, StressSwitchAction, StressCacheAction:
+		if s.Workers <= 0 {
+			return errors.New("workers should always be positive")
+		}
+	case StressHDDAction:
+		if s.Workers <= 0 {
+			return errors.New("workers should always be positive")
+		}
+		if _, err := s.hddBytes(); err != nil {
+			return err
+		}
+	case StressCustomAction:
+		if len(s.Stressors) == 0 {
+			return errors.New("stressors not provided")
+		}
+		for _, stressor := range s.Stressors {
+			if _, _, err := ParseStressor(stressor); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Errorf("stress action %s not supported", s.Action)
+	}
+
+	if len(s.Profile) > 0 {
+		if s.Action != StressCPUAction && s.Action != StressMemAction {
+			return errors.Errorf("load profile is not supported by stress action %s", s.Action)
+		}
+		if s.Action == StressMemAction && len(s.Size) > 0 {
+			return errors.New("size and load profile can't be set at the same time")
+		}
+		if _, err := ParseLoadProfile(s.Profile); err != nil {
+			return err
+		}
+	}
+
+	if len(s.ContainerID) > 0 && len(s.CGroup) > 0 {
+		return errors.New("container id and cgroup can't be set at the same time")
+	}
+
+	if len(s.ContainerID) > 0 {
+		if _, err := container.RuntimeOf(s.ContainerID); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	return nil
 }
 
+// StressorArgs returns the stress-ng arguments of the stressors other than cpu and mem,
+// which are normalized by chaos mesh.
+func (s StressCommand) StressorArgs() ([]string, error) {
+	var args []string
+	switch s.Action {
+	case StressIOAction, StressForkAction, StressSwitchAction, StressCacheAction:
+		args = []string{"--" + s.Action, strconv.Itoa(s.Workers)}
+	case StressHDDAction:
+		args = []string{"--hdd", strconv.Itoa(s.Workers)}
+		bytes, err := s.hddBytes()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes) > 0 {
+			args = append(args, "--hdd-bytes", bytes)
+		}
+	case StressCustomAction:
+		for _, stressor := range s.Stressors {
+			name, workers, err := ParseStressor(stressor)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--"+name, strconv.Itoa(workers))
+		}
+	default:
+		return nil, errors.Errorf("stress action %s not supported", s.Action)
+	}
+
+	for _, option := range s.Options {
+		args = append(args, strings.Fields(option)...)
+	}
+	return args, nil
+}
+
+// hddBytes returns the --hdd-bytes argument of stress-ng, which is empty if not set.
+func (s StressCommand) hddBytes() (string, error) {
+	if len(s.HDDBytes) == 0 || strings.HasSuffix(s.HDDBytes, "%") {
+		return s.HDDBytes, nil
+	}
+
+	bytes, err := utils.ParseUnit(s.HDDBytes)
+	if err != nil {
+		return "", errors.Annotatef(err, "invalid hdd bytes %s", s.HDDBytes)
+	}
+	return strconv.FormatUint(bytes, 10), nil
+}
+
+// ParseStressor parses the stressor formatted as <name>[:<workers>].
+func ParseStressor(stressor string) (string, int, error) {
+	parts := strings.SplitN(stressor, ":", 2)
+	if !stressorNameRegexp.MatchString(parts[0]) {
+		return "", 0, errors.Errorf("invalid stressor name %s", parts[0])
+	}
+	if len(parts) == 1 {
+		return parts[0], 1, nil
+	}
+
+	workers, err := strconv.Atoi(parts[1])
+	if err != nil || workers < 0 {
+		return "", 0, errors.Errorf("invalid workers of stressor %s", stressor)
+	}
+	return parts[0], workers, nil
+}
+
 func (s StressCommand) RecoverData() string {
 	data, _ := json.Marshal(s)
 
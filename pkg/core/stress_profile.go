@@ -0,0 +1,179 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+)
+
+const (
+	ProfileRamp = "ramp"
+	ProfileStep = "step"
+	ProfileSine = "sine"
+
+	defaultProfileSteps = 5
+)
+
+// LoadSegment is a segment of a load profile, it is formatted as
+// <shape>:<from>-<to>:<duration>[:<count>], such as ramp:10-90:5m.
+// The count is the number of steps of a step segment, or the number
+// of periods of a sine segment which oscillates between from and to.
+type LoadSegment struct {
+	Shape    string
+	From     int
+	To       int
+	Duration time.Duration
+	Count    int
+}
+
+// LoadProfile is a load which changes over time, the segments are applied one
+// after another and the load stays at the end of the last segment afterwards.
+type LoadProfile []LoadSegment
+
+// ParseLoadSegment parses the load segment formatted as <shape>:<from>-<to>:<duration>[:<count>].
+func ParseLoadSegment(spec string) (LoadSegment, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return LoadSegment{}, errors.Errorf("invalid load profile %s, it should be <shape>:<from>-<to>:<duration>[:<count>]", spec)
+	}
+
+	segment := LoadSegment{Shape: parts[0], Count: 1}
+	switch segment.Shape {
+	case ProfileRamp:
+		if len(parts) == 4 {
+			return LoadSegment{}, errors.Errorf("invalid load profile %s, ramp doesn't have a count", spec)
+		}
+	case ProfileStep:
+		segment.Count = defaultProfileSteps
+	case ProfileSine:
+	default:
+		return LoadSegment{}, errors.Errorf("invalid load profile %s, the shape should be ramp, step or sine", spec)
+	}
+
+	bounds := strings.SplitN(parts[1], "-", 2)
+	if len(bounds) != 2 {
+		return LoadSegment{}, errors.Errorf("invalid load profile %s, the load should be <from>-<to>", spec)
+	}
+	var err error
+	if segment.From, err = parsePercent(bounds[0]); err != nil {
+		return LoadSegment{}, errors.Annotatef(err, "invalid load profile %s", spec)
+	}
+	if segment.To, err = parsePercent(bounds[1]); err != nil {
+		return LoadSegment{}, errors.Annotatef(err, "invalid load profile %s", spec)
+	}
+
+	if segment.Duration, err = time.ParseDuration(parts[2]); err != nil {
+		return LoadSegment{}, errors.Annotatef(err, "invalid load profile %s", spec)
+	}
+	if segment.Duration <= 0 {
+		return LoadSegment{}, errors.Errorf("invalid load profile %s, the duration should be positive", spec)
+	}
+
+	if len(parts) == 4 {
+		if segment.Count, err = strconv.Atoi(parts[3]); err != nil || segment.Count <= 0 {
+			return LoadSegment{}, errors.Errorf("invalid load profile %s, the count should be positive", spec)
+		}
+	}
+
+	return segment, nil
+}
+
+func parsePercent(s string) (int, error) {
+	p, err := strconv.Atoi(s)
+	if err != nil || p < 0 || p > 100 {
+		return 0, errors.Errorf("load %s should be a percent between 0 and 100", s)
+	}
+	return p, nil
+}
+
+// ParseLoadProfile parses the load segments of the profile.
+func ParseLoadProfile(specs []string) (LoadProfile, error) {
+	var profile LoadProfile
+	for _, spec := range specs {
+		segment, err := ParseLoadSegment(spec)
+		if err != nil {
+			return nil, err
+		}
+		profile = append(profile, segment)
+	}
+	return profile, nil
+}
+
+// ReadLoadProfile reads the load segments from a profile file, which has a segment per line.
+// Empty lines and lines starting with # are ignored.
+func ReadLoadProfile(r io.Reader) ([]string, error) {
+	var specs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		specs = append(specs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := ParseLoadProfile(specs); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// LoadAt returns the load percent after the elapsed time since the profile started.
+func (p LoadProfile) LoadAt(elapsed time.Duration) int {
+	if len(p) == 0 {
+		return 0
+	}
+
+	for _, segment := range p {
+		if elapsed < segment.Duration {
+			return segment.loadAt(elapsed)
+		}
+		elapsed -= segment.Duration
+	}
+	return p[len(p)-1].loadAt(p[len(p)-1].Duration)
+}
+
+func (s LoadSegment) loadAt(elapsed time.Duration) int {
+	progress := float64(elapsed) / float64(s.Duration)
+	if progress > 1 {
+		progress = 1
+	}
+	delta := float64(s.To - s.From)
+
+	var load float64
+	switch s.Shape {
+	case ProfileRamp:
+		load = float64(s.From) + delta*progress
+	case ProfileStep:
+		if s.Count == 1 {
+			return s.To
+		}
+		step := math.Min(math.Floor(progress*float64(s.Count)), float64(s.Count-1))
+		load = float64(s.From) + delta*step/float64(s.Count-1)
+	case ProfileSine:
+		// starts from the middle and rises towards to first
+		load = float64(s.From) + delta*(1+math.Sin(2*math.Pi*progress*float64(s.Count)))/2
+	}
+	return int(math.Round(load))
+}
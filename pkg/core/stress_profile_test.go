@@ -0,0 +1,62 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLoadProfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	profile, err := ParseLoadProfile([]string{"ramp:10-90:4m", "step:90-30:3m:3", "sine:20-80:4m:2"})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(profile).Should(HaveLen(3))
+
+	for elapsed, load := range map[time.Duration]int{
+		0:                              10,
+		time.Minute:                    30,
+		2 * time.Minute:                50,
+		4*time.Minute + 30*time.Second: 90,
+		5*time.Minute + 30*time.Second: 60,
+		6*time.Minute + 59*time.Second: 30,
+		7 * time.Minute:                50,
+		7*time.Minute + 30*time.Second: 80,
+		8*time.Minute + 30*time.Second: 20,
+		time.Hour:                      50,
+	} {
+		g.Expect(profile.LoadAt(elapsed)).Should(Equal(load), "elapsed %s", elapsed)
+	}
+
+	for _, spec := range []string{"ramp:10-90", "ramp:10-90:5m:2", "saw:10-90:5m", "ramp:10:5m", "ramp:10-101:5m", "step:10-90:0s", "sine:10-90:5m:0"} {
+		_, err = ParseLoadSegment(spec)
+		g.Expect(err).Should(HaveOccurred(), spec)
+	}
+
+	specs, err := ReadLoadProfile(strings.NewReader("# warm up\nramp:0-50:1m\n\nstep:50-100:1m\n"))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(specs).Should(Equal([]string{"ramp:0-50:1m", "step:50-100:1m"}))
+
+	s := NewStressCommand()
+	s.Action = StressCPUAction
+	s.Profile = specs
+	g.Expect(s.Validate()).Should(Succeed())
+	s.Action = StressIOAction
+	s.Workers = 1
+	g.Expect(s.Validate()).ShouldNot(Succeed())
+}
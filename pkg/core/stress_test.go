@@ -0,0 +1,69 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestStressorArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := NewStressCommand()
+	s.Action = StressIOAction
+	s.Workers = 2
+	s.Options = []string{"--io-ops 1000"}
+	g.Expect(s.Validate()).Should(Succeed())
+	args, err := s.StressorArgs()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(args).Should(Equal([]string{"--io", "2", "--io-ops", "1000"}))
+
+	s.Workers = 0
+	g.Expect(s.Validate()).ShouldNot(Succeed())
+
+	s.Action = StressHDDAction
+	s.Workers = 1
+	s.Options = nil
+	s.HDDBytes = "1KB"
+	g.Expect(s.Validate()).Should(Succeed())
+	args, err = s.StressorArgs()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(args).Should(Equal([]string{"--hdd", "1", "--hdd-bytes", "1000"}))
+
+	s.HDDBytes = "10%"
+	args, err = s.StressorArgs()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(args).Should(Equal([]string{"--hdd", "1", "--hdd-bytes", "10%"}))
+
+	s.HDDBytes = "1XB"
+	g.Expect(s.Validate()).ShouldNot(Succeed())
+
+	s.Action = StressCustomAction
+	g.Expect(s.Validate()).ShouldNot(Succeed())
+	s.Stressors = []string{"matrix:2", "pipe"}
+	g.Expect(s.Validate()).Should(Succeed())
+	args, err = s.StressorArgs()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(args).Should(Equal([]string{"--matrix", "2", "--pipe", "1"}))
+
+	for _, stressor := range []string{"--timeout", "matrix:-1", "matrix:two", "Matrix"} {
+		s.Stressors = []string{stressor}
+		g.Expect(s.Validate()).ShouldNot(Succeed())
+	}
+
+	s.Action = "disk"
+	g.Expect(s.Validate()).ShouldNot(Succeed())
+}
@@ -15,28 +15,129 @@ package crclient
 
 import (
 	"context"
+	"sync"
 
 	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/crclients"
+
+	"github.com/chaos-mesh/chaosd/pkg/config"
+	"github.com/chaos-mesh/chaosd/pkg/container"
 )
 
-func NewNodeCRClient(pid int) crclients.ContainerRuntimeInfoClient {
+func NewNodeCRClient(pid int, conf *config.Config) *NodeCRClient {
 	return &NodeCRClient{
-		Pid: uint32(pid),
+		Pid:     uint32(pid),
+		conf:    conf,
+		clients: make(map[string]container.CRIClient),
 	}
 }
 
+// NewContainerRuntimeInfoClient provides the NodeCRClient to the chaos daemon server.
+func NewContainerRuntimeInfoClient(n *NodeCRClient) crclients.ContainerRuntimeInfoClient {
+	return n
+}
+
+// NewCRIClient provides the NodeCRClient to the container attacks.
+func NewCRIClient(n *NodeCRClient) container.CRIClient {
+	return n
+}
+
+// NodeCRClient resolves the empty container ID to the chaosd process itself,
+// and the other container IDs through the container runtime of their prefix.
 type NodeCRClient struct {
-	Pid uint32
+	Pid  uint32
+	conf *config.Config
+
+	mu      sync.Mutex
+	clients map[string]container.CRIClient
+}
+
+func (n *NodeCRClient) GetPidFromContainerID(ctx context.Context, containerID string) (uint32, error) {
+	if len(containerID) == 0 {
+		return n.Pid, nil
+	}
+
+	cli, err := n.criClient(containerID)
+	if err != nil {
+		return 0, err
+	}
+
+	return cli.GetPidFromContainerID(ctx, containerID)
 }
 
-func (n *NodeCRClient) GetPidFromContainerID(_ context.Context, _ string) (uint32, error) {
-	return n.Pid, nil
+func (n *NodeCRClient) ContainerKillByContainerID(ctx context.Context, containerID string) error {
+	if len(containerID) == 0 {
+		return nil
+	}
+
+	cli, err := n.criClient(containerID)
+	if err != nil {
+		return err
+	}
+
+	return cli.ContainerKillByContainerID(ctx, containerID)
 }
 
-func (n *NodeCRClient) ContainerKillByContainerID(_ context.Context, _ string) error {
-	return nil
+func (n *NodeCRClient) FormatContainerID(ctx context.Context, containerID string) (string, error) {
+	if len(containerID) == 0 {
+		return "", nil
+	}
+
+	cli, err := n.criClient(containerID)
+	if err != nil {
+		return "", err
+	}
+
+	return cli.FormatContainerID(ctx, containerID)
 }
 
-func (n *NodeCRClient) FormatContainerID(_ context.Context, _ string) (string, error) {
-	return "", nil
+func (n *NodeCRClient) ContainerPauseByContainerID(ctx context.Context, containerID string) error {
+	cli, err := n.criClient(containerID)
+	if err != nil {
+		return err
+	}
+
+	return cli.ContainerPauseByContainerID(ctx, containerID)
+}
+
+func (n *NodeCRClient) ContainerUnpauseByContainerID(ctx context.Context, containerID string) error {
+	cli, err := n.criClient(containerID)
+	if err != nil {
+		return err
+	}
+
+	return cli.ContainerUnpauseByContainerID(ctx, containerID)
+}
+
+func (n *NodeCRClient) ContainerRestartByContainerID(ctx context.Context, containerID string) error {
+	cli, err := n.criClient(containerID)
+	if err != nil {
+		return err
+	}
+
+	return cli.ContainerRestartByContainerID(ctx, containerID)
+}
+
+func (n *NodeCRClient) criClient(containerID string) (container.CRIClient, error) {
+	runtime, err := container.RuntimeOf(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if cli, ok := n.clients[runtime]; ok {
+		return cli, nil
+	}
+
+	// the runtime is decided by the container ID, the other options are kept
+	conf := *n.conf
+	conf.Runtime = runtime
+	cli, err := container.NewCRIClient(&conf)
+	if err != nil {
+		return nil, err
+	}
+	n.clients[runtime] = cli
+
+	return cli, nil
 }
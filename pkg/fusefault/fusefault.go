@@ -0,0 +1,122 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fusefault
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/pingcap/errors"
+)
+
+// Name is the subtype of the file system, it's mounted as "fuse.chaosd".
+const Name = "chaosd"
+
+// Options describes the file system and the faults injected into it.
+type Options struct {
+	// Path is the directory whose files are served by the file system.
+	Path string
+	// MountPoint is where the file system is mounted, the path itself is mounted over if they are the same.
+	MountPoint string
+
+	// Latency delays the faulted operations.
+	Latency time.Duration
+	// Errno fails the faulted operations, 0 means the operations only delay.
+	Errno syscall.Errno
+	// Percent is the percent of the operations which are faulted.
+	Percent int
+	// Methods are the faulted operations, such as read and write, all operations if empty.
+	Methods []string
+}
+
+type injector struct {
+	latency time.Duration
+	errno   syscall.Errno
+	percent int
+	methods map[string]bool
+}
+
+// inject delays the operation or returns the errno to fail it.
+func (i *injector) inject(ctx context.Context, method string) syscall.Errno {
+	if len(i.methods) > 0 && !i.methods[method] {
+		return fs.OK
+	}
+	if rand.Intn(100) >= i.percent {
+		return fs.OK
+	}
+
+	if i.latency > 0 {
+		select {
+		case <-ctx.Done():
+			return syscall.EINTR
+		case <-time.After(i.latency):
+		}
+	}
+	return i.errno
+}
+
+// Mount mounts the loopback file system of the path with the faults, and serves it in the background.
+func Mount(opts Options) (*fuse.Server, error) {
+	path := opts.Path
+	if opts.MountPoint == opts.Path {
+		// the files under the path are hidden by the mount, they are still reachable
+		// by the directory opened before mounting
+		fd, err := syscall.Open(opts.Path, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		path = fmt.Sprintf("/proc/self/fd/%d", fd)
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	i := &injector{
+		latency: opts.Latency,
+		errno:   opts.Errno,
+		percent: opts.Percent,
+		methods: make(map[string]bool),
+	}
+	for _, method := range opts.Methods {
+		i.methods[method] = true
+	}
+
+	root := &fs.LoopbackRoot{
+		Path: path,
+		Dev:  uint64(st.Dev),
+		NewNode: func(rootData *fs.LoopbackRoot, _ *fs.Inode, _ string, _ *syscall.Stat_t) fs.InodeEmbedder {
+			return &faultNode{LoopbackNode: fs.LoopbackNode{RootData: rootData}, injector: i}
+		},
+	}
+
+	server, err := fs.Mount(opts.MountPoint, root.NewNode(root, nil, "", &st), &fs.Options{
+		MountOptions: fuse.MountOptions{
+			// the file system is served by root for the processes of all users,
+			// the permissions are checked by the kernel instead
+			AllowOther:  true,
+			Options:     []string{"default_permissions"},
+			FsName:      opts.Path,
+			Name:        Name,
+			DirectMount: true,
+		},
+	})
+	return server, errors.WithStack(err)
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fusefault
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMountOverPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	if _, err := os.Stat("/dev/fuse"); err != nil || os.Geteuid() != 0 {
+		t.Skip("mounting the file system requires root and /dev/fuse")
+	}
+
+	dir, err := ioutil.TempDir("", "chaosd-fuse")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "existing"), []byte("data"), 0644)).Should(Succeed())
+
+	server, err := Mount(Options{
+		Path:       dir,
+		MountPoint: dir,
+		Errno:      syscall.EIO,
+		Percent:    100,
+		Methods:    []string{"write"},
+	})
+	if err != nil {
+		t.Skipf("mounting the file system is not permitted: %v", err)
+	}
+	defer func() {
+		if err := server.Unmount(); err != nil {
+			_ = ForceUnmount(dir)
+		}
+	}()
+
+	mounted, err := Mounted(dir)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(mounted).Should(BeTrue())
+
+	// the files under the path are served by the file system
+	data, err := ioutil.ReadFile(filepath.Join(dir, "existing"))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(string(data)).Should(Equal("data"))
+
+	err = ioutil.WriteFile(filepath.Join(dir, "new"), []byte("data"), 0644)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(errors.Is(err, syscall.EIO)).Should(BeTrue())
+
+	g.Expect(server.Unmount()).Should(Succeed())
+	mounted, err = Mounted(dir)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(mounted).Should(BeFalse())
+}
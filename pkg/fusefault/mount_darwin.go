@@ -0,0 +1,43 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fusefault
+
+import (
+	"strings"
+
+	"github.com/pingcap/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Mounted returns whether the file system is mounted on the mount point.
+func Mounted(mountPoint string) (bool, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(mountPoint, &st); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	var fsType strings.Builder
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		fsType.WriteByte(byte(c))
+	}
+	return strings.Contains(fsType.String(), "fuse"), nil
+}
+
+// ForceUnmount unmounts the file system even if its files are still in use.
+func ForceUnmount(mountPoint string) error {
+	return errors.WithStack(unix.Unmount(mountPoint, unix.MNT_FORCE))
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fusefault
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Mounted returns whether the file system is mounted on the mount point.
+func Mounted(mountPoint string) (bool, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	// the mount point is escaped in mountinfo, such as \040 for the space
+	escaped := strings.NewReplacer(" ", `\040`, "\t", `\011`, "\n", `\012`, `\`, `\134`).Replace(mountPoint)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[4] != escaped {
+			continue
+		}
+		for i, field := range fields {
+			if field == "-" && i+1 < len(fields) && fields[i+1] == "fuse."+Name {
+				return true, nil
+			}
+		}
+	}
+	return false, errors.WithStack(scanner.Err())
+}
+
+// ForceUnmount detaches the file system even if its files are still in use.
+func ForceUnmount(mountPoint string) error {
+	return errors.WithStack(unix.Unmount(mountPoint, unix.MNT_DETACH))
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fusefault
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+// faultNode is a loopback node which injects faults into the operations on it.
+type faultNode struct {
+	fs.LoopbackNode
+
+	injector *injector
+}
+
+func (n *faultNode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if errno := n.injector.inject(ctx, "open"); errno != fs.OK {
+		return nil, 0, errno
+	}
+	fh, fuseFlags, errno := n.LoopbackNode.Open(ctx, flags)
+	if errno != fs.OK {
+		return nil, 0, errno
+	}
+	return &faultFile{file: fh, injector: n.injector}, fuseFlags, fs.OK
+}
+
+func (n *faultNode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	if errno := n.injector.inject(ctx, "create"); errno != fs.OK {
+		return nil, nil, 0, errno
+	}
+	inode, fh, fuseFlags, errno := n.LoopbackNode.Create(ctx, name, flags, mode, out)
+	if errno != fs.OK {
+		return nil, nil, 0, errno
+	}
+	return inode, &faultFile{file: fh, injector: n.injector}, fuseFlags, fs.OK
+}
+
+func (n *faultNode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if errno := n.injector.inject(ctx, "mkdir"); errno != fs.OK {
+		return nil, errno
+	}
+	return n.LoopbackNode.Mkdir(ctx, name, mode, out)
+}
+
+func (n *faultNode) Unlink(ctx context.Context, name string) syscall.Errno {
+	if errno := n.injector.inject(ctx, "unlink"); errno != fs.OK {
+		return errno
+	}
+	return n.LoopbackNode.Unlink(ctx, name)
+}
+
+func (n *faultNode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	if errno := n.injector.inject(ctx, "rmdir"); errno != fs.OK {
+		return errno
+	}
+	return n.LoopbackNode.Rmdir(ctx, name)
+}
+
+func (n *faultNode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if errno := n.injector.inject(ctx, "rename"); errno != fs.OK {
+		return errno
+	}
+	return n.LoopbackNode.Rename(ctx, name, newParent, newName, flags)
+}
+
+// CopyFileRange isn't supported by the faulted files, the kernel falls back to read and write.
+func (n *faultNode) CopyFileRange(context.Context, fs.FileHandle, uint64, *fs.Inode, fs.FileHandle, uint64, uint64, uint64) (uint32, syscall.Errno) {
+	return 0, syscall.ENOSYS
+}
+
+// faultFile is a loopback file which injects faults into the operations on it.
+type faultFile struct {
+	file     fs.FileHandle
+	injector *injector
+}
+
+var _ = (fs.FileReader)((*faultFile)(nil))
+var _ = (fs.FileWriter)((*faultFile)(nil))
+var _ = (fs.FileFsyncer)((*faultFile)(nil))
+var _ = (fs.FileFlusher)((*faultFile)(nil))
+var _ = (fs.FileReleaser)((*faultFile)(nil))
+var _ = (fs.FileGetattrer)((*faultFile)(nil))
+var _ = (fs.FileSetattrer)((*faultFile)(nil))
+var _ = (fs.FileLseeker)((*faultFile)(nil))
+var _ = (fs.FileAllocater)((*faultFile)(nil))
+
+func (f *faultFile) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	if errno := f.injector.inject(ctx, "read"); errno != fs.OK {
+		return nil, errno
+	}
+	return f.file.(fs.FileReader).Read(ctx, dest, off)
+}
+
+func (f *faultFile) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	if errno := f.injector.inject(ctx, "write"); errno != fs.OK {
+		return 0, errno
+	}
+	return f.file.(fs.FileWriter).Write(ctx, data, off)
+}
+
+func (f *faultFile) Fsync(ctx context.Context, flags uint32) syscall.Errno {
+	if errno := f.injector.inject(ctx, "fsync"); errno != fs.OK {
+		return errno
+	}
+	return f.file.(fs.FileFsyncer).Fsync(ctx, flags)
+}
+
+func (f *faultFile) Flush(ctx context.Context) syscall.Errno {
+	if errno := f.injector.inject(ctx, "flush"); errno != fs.OK {
+		return errno
+	}
+	return f.file.(fs.FileFlusher).Flush(ctx)
+}
+
+func (f *faultFile) Release(ctx context.Context) syscall.Errno {
+	return f.file.(fs.FileReleaser).Release(ctx)
+}
+
+func (f *faultFile) Getattr(ctx context.Context, out *fuse.AttrOut) syscall.Errno {
+	return f.file.(fs.FileGetattrer).Getattr(ctx, out)
+}
+
+func (f *faultFile) Setattr(ctx context.Context, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	return f.file.(fs.FileSetattrer).Setattr(ctx, in, out)
+}
+
+func (f *faultFile) Lseek(ctx context.Context, off uint64, whence uint32) (uint64, syscall.Errno) {
+	return f.file.(fs.FileLseeker).Lseek(ctx, off, whence)
+}
+
+func (f *faultFile) Allocate(ctx context.Context, off uint64, size uint64, mode uint32) syscall.Errno {
+	return f.file.(fs.FileAllocater).Allocate(ctx, off, size, mode)
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cpuPeriod is the period in which a worker is busy for load percent of the time.
+const cpuPeriod = 100 * time.Millisecond
+
+// cpuGenerator loads every worker to the percent of a CPU.
+type cpuGenerator struct {
+	load int64
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newCPUGenerator(workers int) *cpuGenerator {
+	g := &cpuGenerator{done: make(chan struct{})}
+	g.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go g.work()
+	}
+	return g
+}
+
+func (g *cpuGenerator) work() {
+	defer g.wg.Done()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		default:
+		}
+
+		busy := cpuPeriod * time.Duration(atomic.LoadInt64(&g.load)) / 100
+		start := time.Now()
+		for time.Since(start) < busy {
+		}
+		time.Sleep(cpuPeriod - busy)
+	}
+}
+
+func (g *cpuGenerator) setLoad(load int) error {
+	atomic.StoreInt64(&g.load, int64(load))
+	return nil
+}
+
+func (g *cpuGenerator) stop() {
+	close(g.done)
+	g.wg.Wait()
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// ioReportInterval is the interval to log the throughput of the io load.
+const ioReportInterval = 10 * time.Second
+
+// IOOptions describes a sustained io load on a file.
+type IOOptions struct {
+	Path string
+	// Size is the size of the file region which is read and written.
+	Size      int64
+	BlockSize int64
+	// ReadPercent is the percent of reads, the others are writes.
+	ReadPercent int
+	Random      bool
+	// BytesPerSecond and IOPS limit the rate of the io, 0 means unlimited.
+	BytesPerSecond int64
+	IOPS           int64
+}
+
+// interval returns the interval between two io operations, 0 means unlimited.
+func (o IOOptions) interval() time.Duration {
+	var interval time.Duration
+	if o.BytesPerSecond > 0 {
+		interval = time.Duration(int64(time.Second) * o.BlockSize / o.BytesPerSecond)
+	}
+	if o.IOPS > 0 {
+		if byIOPS := time.Second / time.Duration(o.IOPS); byIOPS > interval {
+			interval = byIOPS
+		}
+	}
+	return interval
+}
+
+// RunIO reads and writes the file at the rate of the options until the context is done.
+func RunIO(ctx context.Context, opts IOOptions) error {
+	flag := os.O_RDWR | os.O_SYNC
+	if opts.ReadPercent == 100 {
+		flag = os.O_RDONLY
+	}
+	f, err := os.OpenFile(opts.Path, flag, 0)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	blocks := opts.Size / opts.BlockSize
+	if blocks == 0 {
+		return errors.Errorf("the size %d is smaller than the block size %d", opts.Size, opts.BlockSize)
+	}
+
+	buf := make([]byte, opts.BlockSize)
+	rand.Read(buf)
+
+	interval := opts.interval()
+	start := time.Now()
+	lastReport, ops := start, int64(0)
+	for ; ; ops++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		if interval > 0 {
+			if wait := time.Until(start.Add(time.Duration(ops) * interval)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		block := ops % blocks
+		if opts.Random {
+			block = rand.Int63n(blocks)
+		}
+		offset := block * opts.BlockSize
+
+		if rand.Intn(100) < opts.ReadPercent {
+			if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+				return errors.WithStack(err)
+			}
+			// the next read of the block should hit the disk instead of the page cache
+			dropCache(f, offset, opts.BlockSize)
+		} else {
+			if _, err := f.WriteAt(buf, offset); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if time.Since(lastReport) >= ioReportInterval {
+			elapsed := time.Since(start).Seconds()
+			log.Info("io load", zap.Float64("iops", float64(ops)/elapsed),
+				zap.Float64("bytes_per_second", float64(ops*opts.BlockSize)/elapsed))
+			lastReport = time.Now()
+		}
+	}
+}
+
+// PrepareIOFile extends the file to the size with data, so that the reads hit the disk instead of holes.
+func PrepareIOFile(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	buf := make([]byte, 1<<20)
+	for offset := stat.Size(); offset < size; offset += int64(len(buf)) {
+		n := int64(len(buf))
+		if size-offset < n {
+			n = size - offset
+		}
+		if _, err := f.WriteAt(buf[:n], offset); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(f.Sync())
+}
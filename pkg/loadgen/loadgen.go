@@ -0,0 +1,74 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// adjustInterval is the interval to apply the load of the profile at the moment.
+const adjustInterval = time.Second
+
+// generator generates a load which can be changed at any time.
+type generator interface {
+	setLoad(load int) error
+	stop()
+}
+
+// Run generates the cpu or mem load which follows the profile until the context is done.
+func Run(ctx context.Context, action string, workers int, profile core.LoadProfile) error {
+	var g generator
+	switch action {
+	case core.StressCPUAction:
+		g = newCPUGenerator(workers)
+	case core.StressMemAction:
+		mg, err := newMemGenerator()
+		if err != nil {
+			return err
+		}
+		g = mg
+	default:
+		return errors.Errorf("load profile is not supported by stress action %s", action)
+	}
+	defer g.stop()
+
+	start := time.Now()
+	ticker := time.NewTicker(adjustInterval)
+	defer ticker.Stop()
+
+	last := -1
+	for {
+		load := profile.LoadAt(time.Since(start))
+		if load != last {
+			log.Info("adjust load", zap.String("action", action), zap.Int("load", load))
+			if err := g.setLoad(load); err != nil {
+				return err
+			}
+			last = load
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+func TestMemGeneratorSetLoad(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the total memory is faked, so that only a few chunks are mapped
+	mg := &memGenerator{total: 10 * memChunkSize}
+	defer mg.stop()
+
+	for _, tt := range []struct {
+		load   int
+		chunks int
+	}{
+		{50, 5},
+		{80, 8},
+		{20, 2},
+		{25, 2},
+		{0, 0},
+	} {
+		g.Expect(mg.setLoad(tt.load)).Should(Succeed())
+		g.Expect(mg.chunks).Should(HaveLen(tt.chunks), "load %d", tt.load)
+	}
+
+	g.Expect(mg.setLoad(30)).Should(Succeed())
+	mg.stop()
+	g.Expect(mg.chunks).Should(BeEmpty())
+}
+
+func TestRunCancel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	profile, err := core.ParseLoadProfile([]string{"ramp:10-20:1h"})
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, core.StressCPUAction, 1, profile)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	g.Eventually(done, time.Second).Should(Receive(BeNil()))
+}
+
+func TestRunUnsupportedAction(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	profile, err := core.ParseLoadProfile([]string{"ramp:10-20:1h"})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(Run(context.Background(), core.StressIOAction, 1, profile)).ShouldNot(Succeed())
+}
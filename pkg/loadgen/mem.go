@@ -0,0 +1,73 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"os"
+
+	"github.com/pingcap/errors"
+	"github.com/shirou/gopsutil/mem"
+	"golang.org/x/sys/unix"
+)
+
+// memChunkSize is the size of the memory mapped at a time.
+const memChunkSize = 64 << 20
+
+// memGenerator occupies the percent of the total memory. The memory is mapped
+// out of the go heap, so that it is returned to the system once unmapped.
+type memGenerator struct {
+	total  uint64
+	chunks [][]byte
+}
+
+func newMemGenerator() (*memGenerator, error) {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &memGenerator{total: vm.Total}, nil
+}
+
+func (g *memGenerator) setLoad(load int) error {
+	target := int(g.total * uint64(load) / 100 / memChunkSize)
+
+	for len(g.chunks) > target {
+		last := len(g.chunks) - 1
+		if err := unix.Munmap(g.chunks[last]); err != nil {
+			return errors.WithStack(err)
+		}
+		g.chunks = g.chunks[:last]
+	}
+
+	pageSize := os.Getpagesize()
+	for len(g.chunks) < target {
+		chunk, err := unix.Mmap(-1, 0, memChunkSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// touch every page so that the memory is really allocated
+		for i := 0; i < len(chunk); i += pageSize {
+			chunk[i] = 1
+		}
+		g.chunks = append(g.chunks, chunk)
+	}
+	return nil
+}
+
+func (g *memGenerator) stop() {
+	for _, chunk := range g.chunks {
+		_ = unix.Munmap(chunk)
+	}
+	g.chunks = nil
+}
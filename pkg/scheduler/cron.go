@@ -16,43 +16,143 @@ package scheduler
 import (
 	"time"
 
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
 	cron "github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/clock"
 
 	"github.com/chaos-mesh/chaosd/pkg/core"
 )
 
+// Task is the work of a scheduled experiment.
+type Task interface {
+	// Run applies the experiment, it is called on every firing of the schedule.
+	Run() error
+	// Recover undoes what the runs of the schedule applied,
+	// it is called once when the schedule is removed.
+	Recover() error
+}
+
 type Scheduler struct {
-	*cron.Cron
+	clock clock.Clock
+	store *CronStore
 }
 
-type CronJob struct {
+// entry runs the task of an experiment on every firing of its schedule.
+// A firing is skipped if the previous run is still in progress.
+type entry struct {
 	experiment core.Experiment
-	run        func()
+	schedule   cron.Schedule
+	task       Task
+	// applied reports whether the task has been run since it was scheduled.
+	applied bool
+	stop    chan struct{}
+	done    chan struct{}
 }
 
-// TODO: on running, create new experiment run
-// TODO: write tests for it
-func (cj CronJob) Run() {
-	cj.run()
+func NewScheduler() Scheduler {
+	return newSchedulerWithClock(clock.RealClock{})
 }
 
-func NewScheduler() Scheduler {
-	return Scheduler{cron.New(
-		cron.WithLocation(time.UTC),
-		cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)),
-	)}
+func newSchedulerWithClock(c clock.Clock) Scheduler {
+	return Scheduler{
+		clock: c,
+		store: newCronStore(),
+	}
+}
+
+func (scheduler Scheduler) Schedule(exp core.Experiment, spec string, task Task) error {
+	return scheduler.schedule(exp, spec, task, false)
+}
+
+// Restore schedules an experiment which was scheduled before chaosd restarted.
+// The runs before the restart may have applied it, so it is recovered
+// when the schedule is removed even if it has not been run since.
+func (scheduler Scheduler) Restore(exp core.Experiment, spec string, task Task) error {
+	return scheduler.schedule(exp, spec, task, true)
 }
 
-func (scheduler Scheduler) Schedule(exp core.Experiment, spec string, task func()) error {
-	cj := CronJob{experiment: exp, run: task}
-	entryId, err := scheduler.AddJob(spec, cj)
+func (scheduler Scheduler) schedule(exp core.Experiment, spec string, task Task, applied bool) error {
+	schedule, err := cron.ParseStandard(spec)
 	if err != nil {
-		return err
+		return errors.WithStack(err)
+	}
+
+	scheduler.store.Lock()
+	defer scheduler.store.Unlock()
+
+	if _, ok := scheduler.store.entry[exp.ID]; ok {
+		return errors.Errorf("experiment %s is already scheduled", exp.Uid)
 	}
-	cronStore.entry[exp.ID] = entryId
+
+	e := &entry{
+		experiment: exp,
+		schedule:   schedule,
+		task:       task,
+		applied:    applied,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	scheduler.store.entry[exp.ID] = e
+	go scheduler.run(e)
+
 	return nil
 }
 
+func (scheduler Scheduler) run(e *entry) {
+	defer close(e.done)
+
+	for {
+		// schedules without a time zone are evaluated in UTC
+		now := scheduler.clock.Now().In(time.UTC)
+		next := e.schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+
+		timer := scheduler.clock.NewTimer(next.Sub(now))
+		select {
+		case <-e.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		e.applied = true
+		if err := e.task.Run(); err != nil {
+			log.Error("failed to run scheduled experiment", zap.String("uid", e.experiment.Uid), zap.Error(err))
+		}
+	}
+}
+
+// Scheduled reports whether the experiment is scheduled by this scheduler.
+func (scheduler Scheduler) Scheduled(expId uint) bool {
+	scheduler.store.Lock()
+	defer scheduler.store.Unlock()
+
+	_, ok := scheduler.store.entry[expId]
+	return ok
+}
+
+// Remove stops the schedule of the experiment, waits for the run in progress
+// to finish, and recovers what the runs of the schedule applied.
 func (scheduler Scheduler) Remove(expId uint) error {
-	return nil
+	scheduler.store.Lock()
+	e, ok := scheduler.store.entry[expId]
+	delete(scheduler.store.entry, expId)
+	scheduler.store.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	close(e.stop)
+	<-e.done
+
+	if !e.applied {
+		return nil
+	}
+
+	return errors.WithStack(e.task.Recover())
 }
@@ -0,0 +1,159 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+type fakeTask struct {
+	runs     int32
+	recovers int32
+	// block, if not nil, blocks every run until it is closed
+	block chan struct{}
+}
+
+func (t *fakeTask) Run() error {
+	atomic.AddInt32(&t.runs, 1)
+	if t.block != nil {
+		<-t.block
+	}
+	return nil
+}
+
+func (t *fakeTask) Recover() error {
+	atomic.AddInt32(&t.recovers, 1)
+	return nil
+}
+
+func (t *fakeTask) Runs() int32 {
+	return atomic.LoadInt32(&t.runs)
+}
+
+func (t *fakeTask) Recovers() int32 {
+	return atomic.LoadInt32(&t.recovers)
+}
+
+func newFakeScheduler() (Scheduler, *clock.FakeClock) {
+	c := clock.NewFakeClock(time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	return newSchedulerWithClock(c), c
+}
+
+func TestSchedulerRunsTaskOnSchedule(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, c := newFakeScheduler()
+	task := &fakeTask{}
+	g.Expect(s.Schedule(core.Experiment{ID: 1}, "*/5 * * * *", task)).Should(Succeed())
+
+	g.Eventually(c.HasWaiters).Should(BeTrue())
+	c.Step(4 * time.Minute)
+	g.Consistently(task.Runs, 100*time.Millisecond).Should(BeZero())
+
+	c.Step(time.Minute)
+	g.Eventually(task.Runs).Should(Equal(int32(1)))
+
+	g.Eventually(c.HasWaiters).Should(BeTrue())
+	c.Step(5 * time.Minute)
+	g.Eventually(task.Runs).Should(Equal(int32(2)))
+
+	g.Expect(s.Schedule(core.Experiment{ID: 1}, "*/5 * * * *", task)).ShouldNot(Succeed())
+	g.Expect(s.Schedule(core.Experiment{ID: 2}, "not a spec", task)).ShouldNot(Succeed())
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, c := newFakeScheduler()
+	task := &fakeTask{}
+	g.Expect(s.Schedule(core.Experiment{ID: 1}, "* * * * *", task)).Should(Succeed())
+	g.Expect(s.Scheduled(1)).Should(BeTrue())
+	g.Eventually(c.HasWaiters).Should(BeTrue())
+
+	// nothing has been applied yet, so there is nothing to recover
+	g.Expect(s.Remove(1)).Should(Succeed())
+	g.Expect(task.Recovers()).Should(BeZero())
+	g.Expect(s.Scheduled(1)).Should(BeFalse())
+
+	c.Step(time.Minute)
+	g.Consistently(task.Runs, 100*time.Millisecond).Should(BeZero())
+
+	// removing an unknown experiment is a no-op
+	g.Expect(s.Remove(1)).Should(Succeed())
+}
+
+func TestSchedulerRemoveRecoversAppliedRuns(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, c := newFakeScheduler()
+	task := &fakeTask{}
+	g.Expect(s.Schedule(core.Experiment{ID: 1}, "* * * * *", task)).Should(Succeed())
+
+	g.Eventually(c.HasWaiters).Should(BeTrue())
+	c.Step(time.Minute)
+	g.Eventually(task.Runs).Should(Equal(int32(1)))
+
+	g.Expect(s.Remove(1)).Should(Succeed())
+	g.Expect(task.Recovers()).Should(Equal(int32(1)))
+
+	c.Step(time.Minute)
+	g.Consistently(task.Runs, 100*time.Millisecond).Should(Equal(int32(1)))
+}
+
+func TestSchedulerRemoveWaitsForRunInProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, c := newFakeScheduler()
+	task := &fakeTask{block: make(chan struct{})}
+	g.Expect(s.Schedule(core.Experiment{ID: 1}, "* * * * *", task)).Should(Succeed())
+
+	g.Eventually(c.HasWaiters).Should(BeTrue())
+	c.Step(time.Minute)
+	g.Eventually(task.Runs).Should(Equal(int32(1)))
+
+	removed := make(chan error)
+	go func() {
+		removed <- s.Remove(1)
+	}()
+	g.Consistently(removed, 100*time.Millisecond).ShouldNot(Receive())
+	g.Expect(task.Recovers()).Should(BeZero())
+
+	close(task.block)
+	g.Eventually(removed).Should(Receive(BeNil()))
+	g.Expect(task.Recovers()).Should(Equal(int32(1)))
+	g.Expect(task.Runs()).Should(Equal(int32(1)))
+}
+
+func TestSchedulerRestore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, c := newFakeScheduler()
+	task := &fakeTask{}
+	g.Expect(s.Restore(core.Experiment{ID: 1}, "* * * * *", task)).Should(Succeed())
+
+	g.Eventually(c.HasWaiters).Should(BeTrue())
+	c.Step(time.Minute)
+	g.Eventually(task.Runs).Should(Equal(int32(1)))
+
+	g.Expect(s.Restore(core.Experiment{ID: 2}, "* * * * *", task)).Should(Succeed())
+	g.Expect(s.Remove(2)).Should(Succeed())
+	g.Expect(task.Recovers()).Should(Equal(int32(1)))
+}
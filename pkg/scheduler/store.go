@@ -13,10 +13,13 @@
 
 package scheduler
 
-import cron "github.com/robfig/cron/v3"
+import "sync"
 
 type CronStore struct {
-	entry map[uint]cron.EntryID
+	sync.Mutex
+	entry map[uint]*entry
 }
 
-var cronStore = &CronStore{entry: make(map[uint]cron.EntryID, 0)}
+func newCronStore() *CronStore {
+	return &CronStore{entry: make(map[uint]*entry)}
+}
@@ -15,8 +15,10 @@ package chaosd
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/joomcode/errorx"
 	"github.com/pingcap/log"
 	perr "github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -34,6 +36,27 @@ type AttackType interface {
 	Recover(experiment core.Experiment, env Environment) error
 }
 
+// attackOfKind returns the attack type of the experiment kind
+// and an empty attack config for it.
+func attackOfKind(kind string) (AttackType, core.AttackConfig, error) {
+	switch kind {
+	case core.ProcessAttack:
+		return ProcessAttack, core.NewProcessCommand(), nil
+	case core.NetworkAttack:
+		return NetworkAttack, core.NewNetworkCommand(), nil
+	case core.HostAttack:
+		return HostAttack, core.NewHostCommand(), nil
+	case core.StressAttack:
+		return StressAttack, core.NewStressCommand(), nil
+	case core.DiskAttack:
+		return DiskAttack, core.NewDiskOption(), nil
+	case core.ContainerAttack:
+		return ContainerAttack, core.NewContainerCommand(), nil
+	default:
+		return nil, nil, perr.Errorf("chaos experiment kind %s not found", kind)
+	}
+}
+
 func (s *Server) newEnvironment(uid string) Environment {
 	return Environment{
 		AttackUid: uid,
@@ -45,12 +68,20 @@ func (s *Server) newEnvironment(uid string) Environment {
 // an attack for the given attackType.
 // If options.Schedule isn't provided, then the attack is executed immediately.
 // Otherwise the attack is scheduled based on the provided schedule spec and duration.
+// If options.Duration is provided, the experiment is recovered automatically
+// once the duration runs out.
 func (s *Server) ExecuteAttack(attackType AttackType, options core.AttackConfig) (uid string, err error) {
 	if err = options.Validate(); err != nil {
 		err = core.ErrAttackConfigValidation.Wrap(err, "attack config validation failed")
 		return
 	}
 
+	duration, err := options.ScheduleDuration()
+	if err != nil {
+		err = core.ErrAttackConfigValidation.Wrap(err, "attack config validation failed")
+		return
+	}
+
 	uid = uuid.New().String()
 
 	exp := &core.Experiment{
@@ -59,6 +90,11 @@ func (s *Server) ExecuteAttack(attackType AttackType, options core.AttackConfig)
 		Kind:           options.AttackKind(),
 		Action:         options.String(),
 		RecoverCommand: options.RecoverData(),
+		Cron:           options.Cron(),
+	}
+	if duration != nil {
+		recoverAt := time.Now().Add(*duration)
+		exp.RecoverAt = &recoverAt
 	}
 	if err = s.exp.Set(context.Background(), exp); err != nil {
 		err = perr.WithStack(err)
@@ -81,11 +117,19 @@ func (s *Server) ExecuteAttack(attackType AttackType, options core.AttackConfig)
 		if err := s.exp.Update(context.Background(), uid, newStatus, "", options.RecoverData()); err != nil {
 			log.Error("failed to update experiment", zap.Error(err))
 		}
+		if exp.RecoverAt != nil {
+			s.scheduleRecover(uid, *exp.RecoverAt)
+		}
 	}()
 
 	env := s.newEnvironment(uid)
 	if len(options.Cron()) > 0 {
-		if err = s.Cron.Schedule(*exp, options.Cron(), func() { attackType.Attack(options, env) }); err != nil {
+		task := cronAttack{
+			attackType: attackType,
+			options:    options,
+			env:        env,
+		}
+		if err = s.Cron.Schedule(*exp, options.Cron(), task); err != nil {
 			err = perr.WithStack(err)
 			return
 		}
@@ -97,3 +141,72 @@ func (s *Server) ExecuteAttack(attackType AttackType, options core.AttackConfig)
 	}
 	return
 }
+
+// cronAttack applies an attack on every firing of its schedule.
+type cronAttack struct {
+	attackType AttackType
+	options    core.AttackConfig
+	env        Environment
+}
+
+// Run applies the attack and records it as a run of the experiment.
+func (c cronAttack) Run() error {
+	// the experiment may be recovered by another chaosd process
+	exp, err := c.env.Chaos.exp.FindByUid(context.Background(), c.env.AttackUid)
+	if err != nil {
+		return err
+	}
+	if exp.Status != core.Scheduled {
+		log.Info("skip the run of experiment which is no longer scheduled",
+			zap.String("uid", exp.Uid), zap.String("status", exp.Status))
+		return nil
+	}
+
+	run := &core.ExperimentRun{
+		UID:          uuid.New().String(),
+		StartAt:      time.Now(),
+		Status:       core.Running,
+		ExperimentID: exp.ID,
+	}
+	if err = c.env.Chaos.ExpRun.Set(context.Background(), run); err != nil {
+		return perr.WithStack(err)
+	}
+
+	status, msg := core.Success, ""
+	if err = c.attack(); err != nil {
+		status, msg = core.Error, err.Error()
+	}
+	if err := c.env.Chaos.ExpRun.Update(context.Background(), run.UID, status, msg, time.Now()); err != nil {
+		log.Error("failed to update experiment run", zap.String("uid", run.UID), zap.Error(err))
+	}
+
+	return err
+}
+
+func (c cronAttack) attack() error {
+	if err := c.attackType.Attack(c.options, c.env); err != nil {
+		return err
+	}
+
+	// keep the recover data of the experiment up to date with the latest run
+	return c.env.Chaos.exp.Update(context.Background(), c.env.AttackUid, core.Scheduled, "", c.options.RecoverData())
+}
+
+func (c cronAttack) Recover() error {
+	exp := core.Experiment{
+		Uid:            c.env.AttackUid,
+		Kind:           c.options.AttackKind(),
+		Action:         c.options.String(),
+		RecoverCommand: c.options.RecoverData(),
+		Cron:           c.options.Cron(),
+	}
+	if err := c.attackType.Recover(exp, c.env); err != nil {
+		if errorx.IsOfType(err, core.ErrNonRecoverableAttack) || errorx.IsOfType(err, core.ErrAlreadyRecovered) {
+			log.Warn(err.Error(), zap.String("uid", exp.Uid), zap.String("kind", exp.Kind))
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/bpm"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/shirou/gopsutil/process"
+	"go.uber.org/zap"
+)
+
+// stopBackgroundTimeout is the time to wait for a background process to exit after SIGTERM.
+const stopBackgroundTimeout = 10 * time.Second
+
+// startBackground runs the hidden subcommand of chaosd in the background, it keeps running
+// after chaosd exits. It returns the pid and the create time of the process, which are
+// recorded to stop the process when the experiment is recovered.
+func startBackground(args ...string) (int32, int64, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	cmd := bpm.DefaultProcessBuilder(exe, args...).Build()
+	// Build sets Pdeathsig, reset it so that the process survives chaosd,
+	// and the process leads a new process group to be stopped along with its children
+	cmd.Cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	backgroundProcessManager := bpm.NewBackgroundProcessManager()
+	if err := backgroundProcessManager.StartProcess(cmd); err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	proc, err := process.NewProcess(int32(cmd.Process.Pid))
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	createTime, err := proc.CreateTime()
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+	return int32(cmd.Process.Pid), createTime, nil
+}
+
+// backgroundRunning returns whether the background process is still running,
+// the pid may be reused by another process after the original one exits.
+func backgroundRunning(pid int32, createTime int64) (bool, error) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+
+	ct, err := proc.CreateTime()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	if ct != createTime {
+		return false, nil
+	}
+
+	// the exited process may not be reaped yet if chaosd is not its parent
+	status, err := proc.Status()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	return status != "Z", nil
+}
+
+// stopBackground stops the process group of the background process gracefully with SIGTERM,
+// and kills it if it doesn't exit in time.
+func stopBackground(pid int32, createTime int64) error {
+	running, err := backgroundRunning(pid, createTime)
+	if err != nil || !running {
+		return err
+	}
+
+	if err := syscall.Kill(-int(pid), syscall.SIGTERM); err != nil && err != syscall.ESRCH {
+		return errors.WithStack(err)
+	}
+	for deadline := time.Now().Add(stopBackgroundTimeout); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if running, err = backgroundRunning(pid, createTime); err != nil || !running {
+			return err
+		}
+	}
+
+	log.Warn("the background process doesn't exit in time, kill it", zap.Int32("pid", pid))
+	if err := syscall.Kill(-int(pid), syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return errors.WithStack(err)
+	}
+	return nil
+}
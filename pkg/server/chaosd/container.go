@@ -0,0 +1,59 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"encoding/json"
+
+	perr "github.com/pkg/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+type containerAttack struct{}
+
+var ContainerAttack AttackType = containerAttack{}
+
+func (containerAttack) Attack(options core.AttackConfig, env Environment) error {
+	attack := options.(*core.ContainerCommand)
+	cli := env.Chaos.criCli
+
+	var err error
+	switch attack.Action {
+	case core.ContainerKillAction:
+		err = cli.ContainerKillByContainerID(context.Background(), attack.ContainerID)
+	case core.ContainerPauseAction:
+		err = cli.ContainerPauseByContainerID(context.Background(), attack.ContainerID)
+	case core.ContainerRestartAction:
+		err = cli.ContainerRestartByContainerID(context.Background(), attack.ContainerID)
+	default:
+		err = perr.Errorf("container action %s not supported", attack.Action)
+	}
+
+	return perr.WithStack(err)
+}
+
+func (containerAttack) Recover(exp core.Experiment, env Environment) error {
+	attack := &core.ContainerCommand{}
+	if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+		return perr.WithStack(err)
+	}
+
+	if attack.Action != core.ContainerPauseAction {
+		return core.ErrNonRecoverableAttack.New("container %s attack not supported to recover", attack.Action)
+	}
+
+	return perr.WithStack(env.Chaos.criCli.ContainerUnpauseByContainerID(context.Background(), attack.ContainerID))
+}
@@ -14,7 +14,9 @@
 package chaosd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -28,6 +30,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/loadgen"
 	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
@@ -41,8 +44,15 @@ const DDReadPayloadCommand = "dd if=%s of=/dev/null bs=%s count=%s iflag=dsync,f
 func (disk diskAttack) Attack(options core.AttackConfig, env Environment) (err error) {
 	attack := options.(*core.DiskOption)
 
-	if options.String() == core.DiskFillAction {
+	switch options.String() {
+	case core.DiskFillAction:
 		return disk.diskFill(attack)
+	case core.DiskSustainedPayloadAction:
+		return disk.sustainedPayload(attack)
+	case core.DiskFaultAction:
+		return disk.diskFault(attack)
+	case core.DiskInodeFillAction:
+		return disk.inodeFill(attack)
 	}
 	return disk.diskPayload(attack)
 }
@@ -157,32 +167,174 @@ func (diskAttack) diskPayload(payload *core.DiskOption) error {
 	return nil
 }
 
+// DiskPayloadRunnerCommand is the hidden subcommand of "chaosd attack disk" which runs the sustained
+// payload in the background, its argument is the disk option in json.
+const DiskPayloadRunnerCommand = "payload-runner"
+
+// sustainedPayload reads and writes a file at the target rate in the background until the attack is recovered.
+func (diskAttack) sustainedPayload(payload *core.DiskOption) (err error) {
+	// the payload of the previous run of a schedule is replaced
+	if err := stopSustainedPayload(payload); err != nil {
+		return err
+	}
+
+	size, _, _, err := payload.SustainedPayloadOptions()
+	if err != nil {
+		return err
+	}
+
+	if len(payload.FillFile) == 0 {
+		path, created := payload.Path, false
+		if path == "" {
+			path, err = utils.CreateTempFile()
+			created = true
+		} else {
+			path, created, err = createFillFile(path)
+		}
+		if err != nil {
+			log.Error(fmt.Sprintf("unexpected err when creating payload file in %s", payload.Path), zap.Error(err))
+			return err
+		}
+		// the existing files are never written
+		if !created && payload.ReadPercent < 100 {
+			return errors.Errorf("the existing file %s can only be read by the sustained payload", path)
+		}
+		payload.Path = path
+		if created {
+			payload.FillFile = path
+		}
+	}
+
+	defer func() {
+		if err != nil && len(payload.FillFile) > 0 {
+			if err := removeFillFile(payload.FillFile); err != nil {
+				log.Error("unexpected err when removing payload file", zap.Error(err))
+			}
+			payload.FillFile = ""
+		}
+	}()
+
+	if len(payload.FillFile) > 0 {
+		if err = loadgen.PrepareIOFile(payload.Path, size); err != nil {
+			return err
+		}
+	} else if _, err = SustainedPayloadIOOptions(payload); err != nil {
+		return err
+	}
+
+	payload.PayloadPid, payload.PayloadStartTime, err = startBackground("attack", "disk", DiskPayloadRunnerCommand, payload.RecoverData())
+	if err != nil {
+		return err
+	}
+	log.Info("start sustained disk payload", zap.String("path", payload.Path), zap.Int32("pid", payload.PayloadPid))
+	return nil
+}
+
+// stopSustainedPayload stops the sustained payload if it's still running.
+func stopSustainedPayload(payload *core.DiskOption) error {
+	if payload.PayloadPid == 0 {
+		return nil
+	}
+
+	if err := stopBackground(payload.PayloadPid, payload.PayloadStartTime); err != nil {
+		return err
+	}
+	log.Info("stop sustained disk payload", zap.Int32("pid", payload.PayloadPid))
+	payload.PayloadPid, payload.PayloadStartTime = 0, 0
+	return nil
+}
+
+// SustainedPayloadIOOptions returns the io load of the sustained payload.
+func SustainedPayloadIOOptions(payload *core.DiskOption) (loadgen.IOOptions, error) {
+	size, blockSize, rate, err := payload.SustainedPayloadOptions()
+	if err != nil {
+		return loadgen.IOOptions{}, err
+	}
+
+	// an existing file is only read within its size
+	if len(payload.FillFile) == 0 {
+		stat, err := os.Stat(payload.Path)
+		if err != nil {
+			return loadgen.IOOptions{}, errors.WithStack(err)
+		}
+		if stat.Size() < size {
+			size = stat.Size()
+		}
+		if size < blockSize {
+			return loadgen.IOOptions{}, errors.Errorf("the file %s is smaller than the block size %d", payload.Path, blockSize)
+		}
+	}
+
+	return loadgen.IOOptions{
+		Path:           payload.Path,
+		Size:           size,
+		BlockSize:      blockSize,
+		ReadPercent:    payload.ReadPercent,
+		Random:         payload.Random,
+		BytesPerSecond: rate,
+		IOPS:           payload.IOPS,
+	}, nil
+}
+
 const DDFillCommand = "dd if=/dev/zero of=%s bs=%s count=%s iflag=fullblock"
 const FallocateCommand = "fallocate -l %s %s"
 
 // diskFill will execute a dd command (DDFillCommand or FallocateCommand)
 // to fill the disk.
-func (diskAttack) diskFill(fill *core.DiskOption) error {
+func (diskAttack) diskFill(fill *core.DiskOption) (err error) {
+	// the file filled by the previous run of a schedule is replaced
+	if err := stopDiskFillWatcher(fill); err != nil {
+		return err
+	}
+	if len(fill.FillFile) > 0 {
+		if err := removeFillFile(fill.FillFile); err != nil {
+			return err
+		}
+		fill.FillFile = ""
+	}
+
 	if fill.Path == "" {
-		var err error
 		fill.Path, err = utils.CreateTempFile()
 		if err != nil {
 			log.Error(fmt.Sprintf("unexpected err when CreateTempFile in action: %s", fill.Action))
 			return err
 		}
+		fill.FillFile = fill.Path
+	} else {
+		path, created, err := createFillFile(fill.Path)
+		if err != nil {
+			log.Error(fmt.Sprintf("unexpected err when creating fill file in %s", fill.Path), zap.Error(err))
+			return err
+		}
+		fill.Path = path
+		if created {
+			fill.FillFile = path
+		}
 	}
 
 	defer func() {
-		if fill.DestroyFile {
-			err := os.Remove(fill.Path)
+		// the file created by chaosd is not kept if it is destroyed or the fill failed,
+		// while the existing file of the user is never removed
+		if len(fill.FillFile) > 0 && (fill.DestroyFile || err != nil) {
+			err := os.Remove(fill.FillFile)
 			if err != nil {
-				log.Error(fmt.Sprintf("unexpected err when removing file %s", fill.Path), zap.Error(err))
+				log.Error(fmt.Sprintf("unexpected err when removing file %s", fill.FillFile), zap.Error(err))
 			}
+			fill.FillFile = ""
 		}
 	}()
 
-	if fill.Size != "" {
+	var size string
+	if fill.FillByTarget() {
+		bytes, err := fillTargetSize(fill.Path, fill)
+		if err != nil {
+			log.Error("fail to get the size to fill until the target", zap.Error(err))
+			return err
+		}
+		size = strconv.FormatUint(bytes, 10)
+	} else if fill.Size != "" {
 		fill.Size = strings.Trim(fill.Size, " ")
+		size = fill.Size
 	} else if fill.Percent != "" {
 		fill.Percent = strings.Trim(fill.Percent, " ")
 		percent, err := strconv.ParseUint(fill.Percent, 10, 0)
@@ -197,28 +349,94 @@ func (diskAttack) diskFill(fill *core.DiskOption) error {
 			return err
 		}
 		fill.Size = strconv.FormatUint(totalSize*percent/100, 10)
+		size = fill.Size
 	}
 
-	var cmd *exec.Cmd
-	if fill.FillByFallocate {
-		cmd = exec.Command("bash", "-c", fmt.Sprintf(FallocateCommand, fill.Size, fill.Path))
+	if fill.FillByTarget() && size == "0" {
+		log.Info("the disk already reaches the target usage, nothing to fill", zap.String("path", fill.Path))
 	} else {
-		//1Unit means the block size. The bytes size dd read | write is (block size) * (size).
-		cmd = exec.Command("bash", "-c", fmt.Sprintf(DDFillCommand, fill.Path, fill.Size, "1"))
+		var cmd *exec.Cmd
+		if fill.FillByFallocate {
+			cmd = exec.Command("bash", "-c", fmt.Sprintf(FallocateCommand, size, fill.Path))
+		} else {
+			//1Unit means the block size. The bytes size dd read | write is (block size) * (size).
+			cmd = exec.Command("bash", "-c", fmt.Sprintf(DDFillCommand, fill.Path, size, "1"))
+		}
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			log.Error(string(output), zap.Error(err))
+			return err
+		}
+		log.Info(string(output))
 	}
 
-	output, err := cmd.CombinedOutput()
+	if fill.Watch {
+		return startDiskFillWatcher(fill)
+	}
+	return nil
+}
+
+// createFillFile creates the file to fill in the path, and returns whether the file is created by chaosd.
+// A temp file is created if the path is a directory, and the path is used as it is if it's an existing file.
+func createFillFile(path string) (string, bool, error) {
+	stat, err := os.Stat(path)
+	if err == nil {
+		if !stat.IsDir() {
+			return path, false, nil
+		}
+		f, err := ioutil.TempFile(path, "chaosd-fill-")
+		if err != nil {
+			return "", false, errors.WithStack(err)
+		}
+		return f.Name(), true, f.Close()
+	}
+	if !os.IsNotExist(err) {
+		return "", false, errors.WithStack(err)
+	}
 
+	// O_EXCL makes sure that the file isn't created by others in the meantime
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
 	if err != nil {
-		log.Error(string(output), zap.Error(err))
-	} else {
-		log.Info(string(output))
+		return "", false, errors.WithStack(err)
 	}
+	return path, true, f.Close()
+}
 
-	return err
+func removeFillFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	log.Info("remove fill file", zap.String("path", path))
+	return nil
 }
 
 func (diskAttack) Recover(exp core.Experiment, _ Environment) error {
-	log.Info("Recover disk attack will do nothing, because delete | truncate data is too dangerous.")
-	return nil
+	attack := &core.DiskOption{}
+	if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+		return errors.WithStack(err)
+	}
+
+	switch attack.Action {
+	case core.DiskFillAction:
+		if err := stopDiskFillWatcher(attack); err != nil {
+			return err
+		}
+	case core.DiskSustainedPayloadAction:
+		if err := stopSustainedPayload(attack); err != nil {
+			return err
+		}
+	case core.DiskFaultAction:
+		return stopDiskFault(attack)
+	case core.DiskInodeFillAction:
+		return removeFillDir(attack)
+	}
+
+	if len(attack.FillFile) == 0 {
+		log.Info("Recover disk attack will do nothing, because delete | truncate data is too dangerous.")
+		return nil
+	}
+
+	// only the file created by chaosd is removed
+	return removeFillFile(attack.FillFile)
 }
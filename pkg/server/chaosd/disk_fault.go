@@ -0,0 +1,142 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/fusefault"
+)
+
+const (
+	// DiskFaultServerCommand is the hidden subcommand of "chaosd attack disk" which serves
+	// the FUSE file system in the background, its argument is the disk option in json.
+	DiskFaultServerCommand = "fault-server"
+
+	// diskFaultMountTimeout is the time to wait for the FUSE file system to be mounted.
+	diskFaultMountTimeout = 10 * time.Second
+)
+
+// diskFault mounts a FUSE passthrough of the directory which injects the faults into its io.
+func (diskAttack) diskFault(fault *core.DiskOption) (err error) {
+	// the file system of the previous run of a schedule is replaced
+	if err := stopDiskFault(fault); err != nil {
+		return err
+	}
+
+	for _, path := range []*string{&fault.Path, &fault.MountPath} {
+		if len(*path) == 0 {
+			continue
+		}
+		if *path, err = filepath.Abs(*path); err != nil {
+			return errors.WithStack(err)
+		}
+		stat, err := os.Stat(*path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !stat.IsDir() {
+			return errors.Errorf("%s is not a directory", *path)
+		}
+	}
+
+	fault.FaultPid, fault.FaultStartTime, err = startBackground("attack", "disk", DiskFaultServerCommand, fault.RecoverData())
+	if err != nil {
+		return err
+	}
+
+	mountPoint := fault.FaultMountPoint()
+	for deadline := time.Now().Add(diskFaultMountTimeout); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		mounted, err := fusefault.Mounted(mountPoint)
+		if err != nil {
+			return err
+		}
+		if mounted {
+			log.Info("mount disk fault", zap.String("path", mountPoint), zap.Int32("pid", fault.FaultPid))
+			return nil
+		}
+
+		running, err := backgroundRunning(fault.FaultPid, fault.FaultStartTime)
+		if err != nil {
+			return err
+		}
+		if !running {
+			fault.FaultPid, fault.FaultStartTime = 0, 0
+			return errors.Errorf("failed to mount the FUSE file system on %s, FUSE may be unavailable", mountPoint)
+		}
+	}
+
+	if err := stopDiskFault(fault); err != nil {
+		log.Error("unexpected err when stopping disk fault", zap.Error(err))
+	}
+	return errors.Errorf("timeout to mount the FUSE file system on %s", mountPoint)
+}
+
+// stopDiskFault stops the FUSE file system of the fault action if it's still running,
+// and detaches the mount left behind if the process is killed.
+func stopDiskFault(fault *core.DiskOption) error {
+	if fault.FaultPid == 0 {
+		return nil
+	}
+
+	if err := stopBackground(fault.FaultPid, fault.FaultStartTime); err != nil {
+		return err
+	}
+
+	mountPoint := fault.FaultMountPoint()
+	mounted, err := fusefault.Mounted(mountPoint)
+	if err != nil {
+		return err
+	}
+	if mounted {
+		if err := fusefault.ForceUnmount(mountPoint); err != nil {
+			return err
+		}
+	}
+	log.Info("unmount disk fault", zap.String("path", mountPoint), zap.Int32("pid", fault.FaultPid))
+	fault.FaultPid, fault.FaultStartTime = 0, 0
+	return nil
+}
+
+// DiskFaultOptions returns the file system and the faults of the fault action.
+func DiskFaultOptions(fault *core.DiskOption) (fusefault.Options, error) {
+	latency, err := fault.FaultLatency()
+	if err != nil {
+		return fusefault.Options{}, err
+	}
+	errno, err := fault.FaultErrno()
+	if err != nil {
+		return fusefault.Options{}, err
+	}
+	percent, err := fault.FaultPercent()
+	if err != nil {
+		return fusefault.Options{}, err
+	}
+
+	return fusefault.Options{
+		Path:       fault.Path,
+		MountPoint: fault.FaultMountPoint(),
+		Latency:    latency,
+		Errno:      errno,
+		Percent:    percent,
+		Methods:    fault.Methods,
+	}, nil
+}
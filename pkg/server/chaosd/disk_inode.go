@@ -0,0 +1,126 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+// inodeFillDirSize is the number of files in each subdirectory of the inode fill,
+// so that no directory grows too large to be listed and removed.
+const inodeFillDirSize = 10000
+
+// inodeFill creates empty files in a directory owned by chaosd until the inode usage of the disk reaches the target.
+func (diskAttack) inodeFill(fill *core.DiskOption) (err error) {
+	// the files of the previous run of a schedule are replaced
+	if err := removeFillDir(fill); err != nil {
+		return err
+	}
+
+	if fill.Path == "" {
+		if fill.Path, err = os.Getwd(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	fill.FillDir, err = ioutil.TempDir(fill.Path, "chaosd-inode-fill-")
+	if err != nil {
+		log.Error(fmt.Sprintf("unexpected err when creating inode fill directory in %s", fill.Path), zap.Error(err))
+		return errors.WithStack(err)
+	}
+
+	defer func() {
+		if err != nil {
+			if err := removeFillDir(fill); err != nil {
+				log.Error("unexpected err when removing inode fill directory", zap.Error(err))
+			}
+		}
+	}()
+
+	total, free, err := utils.GetDiskInodes(fill.FillDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if total == 0 {
+		return errors.Errorf("the file system of %s doesn't limit inodes", fill.Path)
+	}
+	targetFree, err := fill.TargetFreeInodes(total)
+	if err != nil {
+		return err
+	}
+	if free <= targetFree {
+		log.Info("the disk already reaches the target inode usage, nothing to fill", zap.String("path", fill.Path))
+		return nil
+	}
+
+	count := free - targetFree
+	var created uint64
+	var dir string
+	for created < count {
+		// the subdirectories take inodes too
+		if created%inodeFillDirSize == 0 {
+			dir = filepath.Join(fill.FillDir, strconv.FormatUint(created/inodeFillDirSize, 10))
+			if err = os.Mkdir(dir, 0700); err != nil {
+				break
+			}
+			created++
+			continue
+		}
+
+		var f *os.File
+		if f, err = os.OpenFile(filepath.Join(dir, strconv.FormatUint(created, 10)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600); err != nil {
+			break
+		}
+		if err = f.Close(); err != nil {
+			break
+		}
+		created++
+	}
+	if err != nil {
+		// the inodes may run out before the target because of other processes
+		if pathErr, ok := err.(*os.PathError); !ok || pathErr.Err != syscall.ENOSPC {
+			return errors.WithStack(err)
+		}
+		log.Warn("the inodes run out before the target", zap.String("path", fill.Path))
+		err = nil
+	}
+
+	log.Info("fill inodes", zap.String("dir", fill.FillDir), zap.Uint64("inodes", created))
+	return nil
+}
+
+// removeFillDir removes the directory of the inode fill along with its files.
+func removeFillDir(fill *core.DiskOption) error {
+	if len(fill.FillDir) == 0 {
+		return nil
+	}
+
+	if err := os.RemoveAll(fill.FillDir); err != nil {
+		return errors.WithStack(err)
+	}
+	log.Info("remove inode fill directory", zap.String("dir", fill.FillDir))
+	fill.FillDir = ""
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+const (
+	// DiskFillWatcherCommand is the hidden subcommand of "chaosd attack disk" which
+	// tops the fill file up in the background, its argument is the disk option in json.
+	DiskFillWatcherCommand = "fill-watcher"
+
+	FallocateTopUpCommand = "fallocate -o %d -l %d %s"
+	DDTopUpCommand        = "dd if=/dev/zero of=%s bs=%d count=1 iflag=fullblock oflag=append conv=notrunc"
+
+	// minTopUpSize avoids topping the file up for every small change of the free space.
+	minTopUpSize = 1 << 20
+)
+
+// fillTargetSize returns the bytes to fill in the disk of the path, so that the disk reaches the target usage.
+func fillTargetSize(path string, fill *core.DiskOption) (uint64, error) {
+	dir := filepath.Dir(path)
+	total, err := utils.GetDiskTotalSize(dir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	avail, err := utils.GetDiskAvailSize(dir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	free, err := fill.TargetFreeSize(total)
+	if err != nil {
+		return 0, err
+	}
+	if avail <= free {
+		return 0, nil
+	}
+	return avail - free, nil
+}
+
+// startDiskFillWatcher starts the watcher of the fill file in the background.
+func startDiskFillWatcher(fill *core.DiskOption) (err error) {
+	fill.WatcherPid, fill.WatcherStartTime, err = startBackground("attack", "disk", DiskFillWatcherCommand, fill.RecoverData())
+	if err != nil {
+		return err
+	}
+	log.Info("start disk fill watcher", zap.String("path", fill.Path), zap.Int32("pid", fill.WatcherPid))
+	return nil
+}
+
+// stopDiskFillWatcher stops the watcher of the fill file if it's still running.
+func stopDiskFillWatcher(fill *core.DiskOption) error {
+	if fill.WatcherPid == 0 {
+		return nil
+	}
+
+	if err := stopBackground(fill.WatcherPid, fill.WatcherStartTime); err != nil {
+		return err
+	}
+	log.Info("stop disk fill watcher", zap.Int32("pid", fill.WatcherPid))
+	fill.WatcherPid, fill.WatcherStartTime = 0, 0
+	return nil
+}
+
+// WatchDiskFill tops the fill file up when other processes free space, so that the disk
+// stays at the target usage. It returns when the context is done or the file is removed.
+func WatchDiskFill(ctx context.Context, fill *core.DiskOption) error {
+	ticker := time.NewTicker(fill.WatchIntervalDuration())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		stat, err := os.Stat(fill.Path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				log.Info("the fill file is removed, stop watching", zap.String("path", fill.Path))
+				return nil
+			}
+			return errors.WithStack(err)
+		}
+
+		size, err := fillTargetSize(fill.Path, fill)
+		if err != nil {
+			return err
+		}
+		if size < minTopUpSize {
+			continue
+		}
+
+		var cmd *exec.Cmd
+		if fill.FillByFallocate {
+			cmd = exec.Command("bash", "-c", fmt.Sprintf(FallocateTopUpCommand, stat.Size(), size, fill.Path))
+		} else {
+			cmd = exec.Command("bash", "-c", fmt.Sprintf(DDTopUpCommand, fill.Path, size))
+		}
+		if output, err := cmd.CombinedOutput(); err != nil {
+			log.Error(string(output), zap.Error(err))
+			continue
+		}
+		log.Info("top the fill file up", zap.String("path", fill.Path), zap.Uint64("size", size))
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"time"
+
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// recoverTimer recovers an experiment when its duration runs out.
+type recoverTimer struct {
+	timer *time.Timer
+	done  chan struct{}
+	err   error
+}
+
+// scheduleRecover arms a timer which recovers the experiment at recoverAt.
+// If recoverAt is already passed, the experiment is recovered immediately.
+func (s *Server) scheduleRecover(uid string, recoverAt time.Time) {
+	s.recoverTimersMu.Lock()
+	defer s.recoverTimersMu.Unlock()
+
+	if _, ok := s.recoverTimers[uid]; ok {
+		return
+	}
+
+	log.Info("experiment will be recovered automatically", zap.String("uid", uid), zap.Time("recover_at", recoverAt))
+	s.recoverTimers[uid] = &recoverTimer{
+		timer: time.AfterFunc(time.Until(recoverAt), func() {
+			err := s.RecoverAttack(uid)
+			if err != nil {
+				log.Error("failed to recover experiment after its duration", zap.String("uid", uid), zap.Error(err))
+			}
+			s.finishRecover(uid, err)
+		}),
+		done: make(chan struct{}),
+	}
+}
+
+// finishRecover stops the recover timer of the experiment and wakes up
+// the callers of WaitRecover with the result of the recovery.
+func (s *Server) finishRecover(uid string, err error) {
+	s.recoverTimersMu.Lock()
+	defer s.recoverTimersMu.Unlock()
+
+	rt, ok := s.recoverTimers[uid]
+	if !ok {
+		return
+	}
+
+	rt.timer.Stop()
+	rt.err = err
+	close(rt.done)
+	delete(s.recoverTimers, uid)
+}
+
+// WaitRecover blocks until the experiment is recovered automatically and
+// returns the result of the recovery. It returns nil immediately if the
+// experiment has no pending automatic recovery.
+func (s *Server) WaitRecover(uid string) error {
+	s.recoverTimersMu.Lock()
+	rt, ok := s.recoverTimers[uid]
+	s.recoverTimersMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	<-rt.done
+	return rt.err
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/bpm"
+)
+
+const (
+	// IFBPrefix is the name prefix of the IFB devices created by chaosd.
+	IFBPrefix = "chaos-"
+
+	fileExistsErr   = "File exists"
+	noSuchDeviceErr = "Cannot find device"
+	noSuchQdiscErr  = "Cannot find specified qdisc"
+	invalidQdiscErr = "Invalid handle"
+)
+
+// ifbName returns the name of the IFB device which the ingress traffic of
+// the device is redirected to, it is limited to 15 characters.
+func ifbName(device string) string {
+	return fmt.Sprintf("%s%08x", IFBPrefix, crc32.ChecksumIEEE([]byte(device)))
+}
+
+// setupIFB redirects the ingress traffic of the device to its IFB device,
+// and returns the name of the IFB device.
+func (s *Server) setupIFB(containerID string, device string) (string, error) {
+	ifb := ifbName(device)
+
+	if err := s.runInNetNS(containerID, "ip", []string{"link", "add", ifb, "type", "ifb"}, fileExistsErr); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := s.runInNetNS(containerID, "ip", []string{"link", "set", "dev", ifb, "up"}); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// the filters are removed along with the ingress qdisc, so the redirection is set from scratch
+	if err := s.runInNetNS(containerID, "tc", []string{"qdisc", "del", "dev", device, "ingress"},
+		noSuchQdiscErr, invalidQdiscErr); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := s.runInNetNS(containerID, "tc", []string{"qdisc", "add", "dev", device, "handle", "ffff:", "ingress"}); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := s.runInNetNS(containerID, "tc", []string{
+		"filter", "add", "dev", device, "parent", "ffff:", "protocol", "all",
+		"u32", "match", "u32", "0", "0", "action", "mirred", "egress", "redirect", "dev", ifb,
+	}); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return ifb, nil
+}
+
+// teardownIFB stops redirecting the ingress traffic of the device and removes its IFB device.
+func (s *Server) teardownIFB(containerID string, device string) error {
+	if err := s.runInNetNS(containerID, "tc", []string{"qdisc", "del", "dev", device, "ingress"},
+		noSuchQdiscErr, invalidQdiscErr, noSuchDeviceErr); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := s.runInNetNS(containerID, "ip", []string{"link", "del", ifbName(device)}, noSuchDeviceErr); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// runInNetNS runs the command in the network namespace of the container, or on the host
+// if the container ID is empty. The errors whose output contains one of ignoredErrs are ignored.
+func (s *Server) runInNetNS(containerID string, name string, args []string, ignoredErrs ...string) error {
+	_, err := s.outputInNetNS(containerID, name, args, ignoredErrs...)
+	return err
+}
+
+// outputInNetNS is like runInNetNS but returns the output of the command.
+func (s *Server) outputInNetNS(containerID string, name string, args []string, ignoredErrs ...string) (string, error) {
+	ctx := context.Background()
+	builder := bpm.DefaultProcessBuilder(name, args...).SetContext(ctx)
+	if len(containerID) > 0 {
+		pid, err := s.criCli.GetPidFromContainerID(ctx, containerID)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		builder = builder.SetNS(pid, bpm.NetNS)
+	}
+
+	out, err := builder.Build().CombinedOutput()
+	if err != nil {
+		for _, ignored := range ignoredErrs {
+			if strings.Contains(string(out), ignored) {
+				return string(out), nil
+			}
+		}
+		return "", errors.Errorf("%s %s: %v, output: %s", name, strings.Join(args, " "), err, string(out))
+	}
+
+	return string(out), nil
+}
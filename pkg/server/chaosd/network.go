@@ -17,7 +17,6 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -35,6 +34,14 @@ import (
 	"github.com/chaos-mesh/chaosd/pkg/core"
 )
 
+// IPSetPrefix is the name prefix of the ipsets created by chaosd.
+const IPSetPrefix = "chaosd-"
+
+const (
+	noSuchIPSetErr = "does not exist"
+	noSuchChainErr = "No chain/target/match by that name"
+)
+
 type networkAttack struct{}
 
 var NetworkAttack AttackType = networkAttack{}
@@ -59,7 +66,8 @@ func (networkAttack) Attack(options core.AttackConfig, env Environment) (err err
 			}
 		}
 
-	case core.NetworkDelayAction, core.NetworkLossAction, core.NetworkCorruptAction, core.NetworkDuplicateAction:
+	case core.NetworkDelayAction, core.NetworkLossAction, core.NetworkCorruptAction, core.NetworkDuplicateAction,
+		core.NetworkBandwidthAction:
 		if attack.NeedApplyIPSet() {
 			ipsetName, err = env.Chaos.applyIPSet(attack, env.AttackUid)
 			if err != nil {
@@ -68,7 +76,7 @@ func (networkAttack) Attack(options core.AttackConfig, env Environment) (err err
 		}
 
 		if attack.NeedApplyIptables() {
-			if err = env.Chaos.applyIptables(attack, env.AttackUid); err != nil {
+			if err = env.Chaos.applyIptables(attack, ipsetName, env.AttackUid); err != nil {
 				return errors.WithStack(err)
 			}
 		}
@@ -78,28 +86,44 @@ func (networkAttack) Attack(options core.AttackConfig, env Environment) (err err
 				return errors.WithStack(err)
 			}
 		}
+
+	case core.NetworkPartitionAction:
+		if ipsetName, err = env.Chaos.applyIPSet(attack, env.AttackUid); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err = env.Chaos.applyIptables(attack, ipsetName, env.AttackUid); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// setting iptables chains resets the chains used by tc filters
+		if err = env.Chaos.reapplyTCs(attack.ContainerID); err != nil {
+			return errors.WithStack(err)
+		}
 	}
 
 	return nil
 }
 
 func (s *Server) applyIPSet(attack *core.NetworkCommand, uid string) (string, error) {
-	ipset, err := attack.ToIPSet(fmt.Sprintf("chaos-%s", uid[:16]))
+	ipset, err := attack.ToIPSet(IPSetPrefix + uid[:16])
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 
 	if _, err := s.svr.FlushIPSets(context.Background(), &pb.IPSetsRequest{
-		Ipsets:  []*pb.IPSet{ipset},
-		EnterNS: false,
+		Ipsets:      []*pb.IPSet{ipset},
+		ContainerId: attack.ContainerID,
+		EnterNS:     attack.EnterNS(),
 	}); err != nil {
 		return "", errors.WithStack(err)
 	}
 
 	if err := s.ipsetRule.Set(context.Background(), &core.IPSetRule{
-		Name:       ipset.Name,
-		Cidrs:      strings.Join(ipset.Cidrs, ","),
-		Experiment: uid,
+		Name:        ipset.Name,
+		Cidrs:       strings.Join(ipset.Cidrs, ","),
+		Experiment:  uid,
+		ContainerID: attack.ContainerID,
 	}); err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -107,47 +131,76 @@ func (s *Server) applyIPSet(attack *core.NetworkCommand, uid string) (string, er
 	return ipset.Name, nil
 }
 
-func (s *Server) applyIptables(attack *core.NetworkCommand, uid string) error {
-	iptables, err := s.iptablesRule.List(context.Background())
+func (s *Server) applyIptables(attack *core.NetworkCommand, ipset string, uid string) error {
+	iptables, err := s.iptablesRule.FindByContainer(context.Background(), attack.ContainerID)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	chains := core.IptablesRuleList(iptables).ToChains()
-	newChain, err := attack.ToChain()
+
+	newRules, err := attack.ToIptablesRules(ipset, uid)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if newChain != nil {
-		chains = append(chains, newChain)
+	// the rules of a scheduled attack are applied again on every run
+	rules := make([]*core.IptablesRule, 0, len(iptables)+len(newRules))
+	for _, rule := range iptables {
+		if len(newRules) == 0 || rule.Experiment != uid {
+			rules = append(rules, rule)
+		}
 	}
+	rules = append(rules, newRules...)
 
 	if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
-		Chains:  chains,
-		EnterNS: false,
+		Chains:      core.IptablesRuleList(rules).ToChains(),
+		ContainerId: attack.ContainerID,
+		EnterNS:     attack.EnterNS(),
 	}); err != nil {
 		return errors.WithStack(err)
 	}
 
-	// TODO: cwen0
-	//if err := s.iptablesRule.Set(context.Background(), &core.IptablesRule{
-	//	Name:       newChain.Name,
-	//	IPSets:     strings.Join(newChain.Ipsets, ","),
-	//	Direction:  pb.Chain_Direction_name[int32(newChain.Direction)],
-	//	Experiment: uid,
-	//}); err != nil {
-	//	return errors.WithStack(err)
-	//}
+	if len(newRules) == 0 {
+		return nil
+	}
+
+	if err := s.iptablesRule.DeleteByExperiment(context.Background(), uid); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, rule := range newRules {
+		if err := s.iptablesRule.Set(context.Background(), rule); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
 	return nil
 }
 
-func (s *Server) applyTC(attack *core.NetworkCommand, ipset string, uid string) error {
-	tcRules, err := s.tcRule.FindByDevice(context.Background(), attack.Device)
+func (s *Server) applyTC(attack *core.NetworkCommand, ipset string, uid string) (err error) {
+	device, ingressDevice := attack.Device, ""
+	if attack.Ingress() {
+		ingressDevice = attack.Device
+		if device, err = s.setupIFB(attack.ContainerID, ingressDevice); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	tcRules, err := s.tcRule.FindByDevice(context.Background(), attack.ContainerID, device)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
+	if attack.Ingress() && len(tcRules) == 0 {
+		defer func() {
+			if err == nil {
+				return
+			}
+			if err := s.teardownIFB(attack.ContainerID, ingressDevice); err != nil {
+				log.Error("failed to remove IFB device", zap.Error(err))
+			}
+		}()
+	}
+
 	tcs, err := core.TCRuleList(tcRules).ToTCs()
 	if err != nil {
 		return errors.WithStack(err)
@@ -159,20 +212,21 @@ func (s *Server) applyTC(attack *core.NetworkCommand, ipset string, uid string)
 	}
 
 	tcs = append(tcs, newTC)
-	if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{Tcs: tcs, Device: attack.Device, EnterNS: false}); err != nil {
+	if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{
+		Tcs:         tcs,
+		Device:      device,
+		ContainerId: attack.ContainerID,
+		EnterNS:     attack.EnterNS(),
+	}); err != nil {
 		return errors.WithStack(err)
 	}
 
 	tc := &core.TcParameter{
-		Device: attack.Device,
+		Device: device,
 	}
 	switch attack.Action {
 	case core.NetworkDelayAction:
-		tc.Delay = &core.DelaySpec{
-			Latency:     attack.Latency,
-			Correlation: attack.Correlation,
-			Jitter:      attack.Jitter,
-		}
+		tc.Delay = attack.ToDelaySpec()
 	case core.NetworkLossAction:
 		tc.Loss = &core.LossSpec{
 			Loss:        attack.Percent,
@@ -188,6 +242,8 @@ func (s *Server) applyTC(attack *core.NetworkCommand, ipset string, uid string)
 			Duplicate:   attack.Percent,
 			Correlation: attack.Correlation,
 		}
+	case core.NetworkBandwidthAction:
+		tc.Bandwidth = attack.ToBandwidthSpec()
 	default:
 		return errors.Errorf("network %s attack not supported", attack.Action)
 	}
@@ -198,14 +254,16 @@ func (s *Server) applyTC(attack *core.NetworkCommand, ipset string, uid string)
 	}
 
 	if err := s.tcRule.Set(context.Background(), &core.TCRule{
-		Type:       pb.Tc_Type_name[int32(newTC.Type)],
-		Device:     attack.Device,
-		TC:         string(tcString),
-		IPSet:      newTC.Ipset,
-		Protocal:   newTC.Protocol,
-		SourcePort: newTC.SourcePort,
-		EgressPort: newTC.EgressPort,
-		Experiment: uid,
+		Type:          pb.Tc_Type_name[int32(newTC.Type)],
+		Device:        device,
+		TC:            string(tcString),
+		IPSet:         newTC.Ipset,
+		Protocal:      newTC.Protocol,
+		SourcePort:    newTC.SourcePort,
+		EgressPort:    newTC.EgressPort,
+		Experiment:    uid,
+		ContainerID:   attack.ContainerID,
+		IngressDevice: ingressDevice,
 	}); err != nil {
 		return errors.WithStack(err)
 	}
@@ -315,77 +373,223 @@ func (networkAttack) Recover(exp core.Experiment, env Environment) error {
 		}
 		return env.Chaos.recoverDNSServer(attack)
 
-	case core.NetworkDelayAction, core.NetworkLossAction, core.NetworkCorruptAction, core.NetworkDuplicateAction:
-		if attack.NeedApplyIPSet() {
-			if err := env.Chaos.recoverIPSet(env.AttackUid); err != nil {
+	case core.NetworkDelayAction, core.NetworkLossAction, core.NetworkCorruptAction, core.NetworkDuplicateAction,
+		core.NetworkBandwidthAction:
+		if attack.NeedApplyIptables() {
+			if err := env.Chaos.recoverIptables(env.AttackUid, attack.ContainerID); err != nil {
 				return errors.WithStack(err)
 			}
 		}
 
-		if attack.NeedApplyIptables() {
-			if err := env.Chaos.recoverIptables(env.AttackUid); err != nil {
+		if attack.NeedApplyTC() {
+			if err := env.Chaos.recoverTC(env.AttackUid, attack.ContainerID, attack.Device, attack.Ingress()); err != nil {
 				return errors.WithStack(err)
 			}
 		}
 
-		if attack.NeedApplyTC() {
-			if err := env.Chaos.recoverTC(env.AttackUid, attack.Device); err != nil {
+		if attack.NeedApplyIPSet() {
+			if err := env.Chaos.recoverIPSet(env.AttackUid, attack.ContainerID); err != nil {
 				return errors.WithStack(err)
 			}
 		}
+
+	case core.NetworkPartitionAction:
+		if err := env.Chaos.recoverIptables(env.AttackUid, attack.ContainerID); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := env.Chaos.reapplyTCs(attack.ContainerID); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := env.Chaos.recoverIPSet(env.AttackUid, attack.ContainerID); err != nil {
+			return errors.WithStack(err)
+		}
 	}
 	return nil
 }
 
-func (s *Server) recoverIPSet(uid string) error {
-	if err := s.ipsetRule.DeleteByExperiment(context.Background(), uid); err != nil {
+// recoverIPSet destroys the ipsets of the experiment, it must be called after the iptables
+// and tc rules which refer to the ipsets are recovered. The rules are kept in the store
+// until the ipsets are destroyed, so that a failed recovery can be retried.
+func (s *Server) recoverIPSet(uid string, containerID string) error {
+	rules, err := s.ipsetRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	return nil
+	for _, rule := range rules {
+		if err := s.releaseIPSet(containerID, rule.Name); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := s.runInNetNS(containerID, "ipset", []string{"destroy", rule.Name}, noSuchIPSetErr); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return errors.WithStack(s.ipsetRule.DeleteByExperiment(context.Background(), uid))
 }
 
-func (s *Server) recoverIptables(uid string) error {
-	if err := s.iptablesRule.DeleteByExperiment(context.Background(), uid); err != nil {
+// releaseIPSet deletes the iptables chains which still match the ipset, such as the TC-TABLES
+// chains of the tc filters which are not set again after the tc rules are recovered, the kernel
+// refuses to destroy the ipset in use.
+func (s *Server) releaseIPSet(containerID string, ipset string) error {
+	out, err := s.outputInNetNS(containerID, "iptables", []string{"-w", "-S"})
+	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	iptables, err := s.iptablesRule.List(context.Background())
+	chains, jumps := parseIPSetUsers(out, ipset)
+	for _, jump := range jumps {
+		if err := s.runInNetNS(containerID, "iptables", append([]string{"-w", "-D"}, jump...), noSuchChainErr); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for _, chain := range chains {
+		if err := s.deleteChain(containerID, chain); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// parseIPSetUsers parses the chains whose rules match the ipset, and the rules jumping to
+// these chains without the leading "-A", from the output of "iptables -S".
+func parseIPSetUsers(out string, ipset string) ([]string, [][]string) {
+	var rules [][]string
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 2 && fields[0] == "-A" {
+			rules = append(rules, fields[1:])
+		}
+	}
+
+	users := make(map[string]bool)
+	var chains []string
+	for _, rule := range rules {
+		for i := 1; i+1 < len(rule); i++ {
+			if rule[i] == "--match-set" && rule[i+1] == ipset && !users[rule[0]] {
+				users[rule[0]] = true
+				chains = append(chains, rule[0])
+			}
+		}
+	}
+
+	var jumps [][]string
+	for _, rule := range rules {
+		for i := 1; i+1 < len(rule); i++ {
+			if rule[i] == "-j" && users[rule[i+1]] {
+				jumps = append(jumps, rule)
+			}
+		}
+	}
+	return chains, jumps
+}
+
+// recoverIptables removes the iptables chains of the experiment, the rules are kept in the
+// store until the chains are deleted, so that a failed recovery can be retried.
+func (s *Server) recoverIptables(uid string, containerID string) error {
+	iptables, err := s.iptablesRule.FindByContainer(context.Background(), containerID)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	chains := core.IptablesRuleList(iptables).ToChains()
+	var recovered, rules []*core.IptablesRule
+	for _, rule := range iptables {
+		if rule.Experiment == uid {
+			recovered = append(recovered, rule)
+		} else {
+			rules = append(rules, rule)
+		}
+	}
 
+	// setting the chains of the other experiments removes the jumps to the chains of the experiment
 	if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
-		Chains:  chains,
-		EnterNS: false,
+		Chains:      core.IptablesRuleList(rules).ToChains(),
+		ContainerId: containerID,
+		EnterNS:     len(containerID) > 0,
 	}); err != nil {
 		return errors.WithStack(err)
 	}
 
-	return nil
+	for _, rule := range recovered {
+		if err := s.deleteChain(containerID, rule.Name); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return errors.WithStack(s.iptablesRule.DeleteByExperiment(context.Background(), uid))
+}
+
+// deleteChain flushes and deletes the iptables chain, which must not be referenced any more.
+func (s *Server) deleteChain(containerID string, chain string) error {
+	if err := s.runInNetNS(containerID, "iptables", []string{"-w", "-F", chain}, noSuchChainErr); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(s.runInNetNS(containerID, "iptables", []string{"-w", "-X", chain}, noSuchChainErr))
 }
 
-func (s *Server) recoverTC(uid string, device string) error {
+func (s *Server) recoverTC(uid string, containerID string, device string, ingress bool) error {
 	if err := s.tcRule.DeleteByExperiment(context.Background(), uid); err != nil {
 		return errors.WithStack(err)
 	}
 
-	tcRules, err := s.tcRule.FindByDevice(context.Background(), device)
+	ingressDevice := ""
+	if ingress {
+		ingressDevice, device = device, ifbName(device)
+	}
+
+	tcRules, err := s.tcRule.FindByDevice(context.Background(), containerID, device)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if ingress && len(tcRules) == 0 {
+		return errors.WithStack(s.teardownIFB(containerID, ingressDevice))
+	}
 
 	tcs, err := core.TCRuleList(tcRules).ToTCs()
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{Tcs: tcs, Device: device, EnterNS: false}); err != nil {
+	if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{
+		Tcs:         tcs,
+		Device:      device,
+		ContainerId: containerID,
+		EnterNS:     len(containerID) > 0,
+	}); err != nil {
 		return errors.WithStack(err)
 	}
 
 	return nil
 }
 
+// reapplyTCs sets the tc rules of all devices in the network namespace again.
+func (s *Server) reapplyTCs(containerID string) error {
+	devices, err := s.tcRule.ListGroupDevice(context.Background(), containerID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for device, tcRules := range devices {
+		tcs, err := core.TCRuleList(tcRules).ToTCs()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{
+			Tcs:         tcs,
+			Device:      device,
+			ContainerId: containerID,
+			EnterNS:     len(containerID) > 0,
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
 func (s *Server) updateDNSServer(attack *core.NetworkCommand) error {
 	if _, err := s.svr.SetDNSServer(context.Background(), &pb.SetDNSServerRequest{
 		DnsServer: attack.DNSServer,
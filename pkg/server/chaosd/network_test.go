@@ -0,0 +1,42 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseIPSetUsers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out := `-P INPUT ACCEPT
+-N CHAOS-OUTPUT
+-N TC-TABLES-0
+-N TC-TABLES-1
+-A OUTPUT -j CHAOS-OUTPUT
+-A CHAOS-OUTPUT -j TC-TABLES-0
+-A CHAOS-OUTPUT -j TC-TABLES-1
+-A TC-TABLES-0 -m set --match-set chaosd-93e7ae10-0c13-4d dst -j CLASSIFY --set-class 0001:0004
+-A TC-TABLES-1 -m set --match-set chaosd-1b2f8a2c-5d1e-4c dst -j CLASSIFY --set-class 0001:0005
+`
+	chains, jumps := parseIPSetUsers(out, "chaosd-93e7ae10-0c13-4d")
+	g.Expect(chains).Should(Equal([]string{"TC-TABLES-0"}))
+	g.Expect(jumps).Should(Equal([][]string{{"CHAOS-OUTPUT", "-j", "TC-TABLES-0"}}))
+
+	chains, jumps = parseIPSetUsers(out, "chaosd-00000000-0000-00")
+	g.Expect(chains).Should(BeEmpty())
+	g.Expect(jumps).Should(BeEmpty())
+}
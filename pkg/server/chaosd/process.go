@@ -15,71 +15,313 @@ package chaosd
 
 import (
 	"encoding/json"
+	"os"
+	"os/user"
+	"regexp"
+	"sort"
 	"strconv"
 	"syscall"
 
-	"github.com/mitchellh/go-ps"
+	"github.com/hashicorp/go-multierror"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/shirou/gopsutil/process"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
 
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
 type processAttack struct{}
 
 var ProcessAttack AttackType = processAttack{}
 
-func (processAttack) Attack(options core.AttackConfig, _ Environment) error {
+func (processAttack) Attack(options core.AttackConfig, env Environment) error {
 	attack := options.(*core.ProcessCommand)
 
-	processes, err := ps.Processes()
+	if attack.Action == core.ProcessFlapAction {
+		return flapProcesses(attack, env)
+	}
+
+	pids, err := findAttackedProcesses(attack)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	notFound := true
-	for _, p := range processes {
-		if attack.Process == strconv.Itoa(p.Pid()) || attack.Process == p.Executable() {
-			notFound = false
-			switch attack.Signal {
-			case int(syscall.SIGKILL):
-				err = syscall.Kill(p.Pid(), syscall.SIGKILL)
-			case int(syscall.SIGTERM):
-				err = syscall.Kill(p.Pid(), syscall.SIGTERM)
-			case int(syscall.SIGSTOP):
-				err = syscall.Kill(p.Pid(), syscall.SIGSTOP)
-			default:
-				return errors.Errorf("signal %d is not supported", attack.Signal)
+	_, err = signalProcesses(attack, pids)
+	return errors.WithStack(err)
+}
+
+// findAttackedProcesses is like findProcesses but returns an error if no process is found.
+func findAttackedProcesses(attack *core.ProcessCommand) ([]int, error) {
+	pids, err := findProcesses(attack)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(pids) == 0 {
+		if len(attack.Process) == 0 {
+			err = errors.Errorf("process of user %s not found", attack.User)
+		} else {
+			err = errors.Errorf("process %s not found", attack.Process)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return pids, nil
+}
+
+// signalProcesses sends the signal of the attack to the processes, and their descendants
+// if KillChildren is set. The signaled processes are returned and appended to the PIDs.
+func signalProcesses(attack *core.ProcessCommand, pids []int) ([]int, error) {
+	roots := make(map[int]bool)
+	for _, pid := range pids {
+		roots[pid] = true
+	}
+	if attack.KillChildren {
+		var err error
+		if pids, err = withDescendants(pids); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	var signaled []int
+	for _, pid := range pids {
+		// the descendants may exit along with their parents
+		createTime, err := processCreateTime(pid)
+		if err == nil {
+			err = syscall.Kill(pid, syscall.Signal(attack.Signal))
+		}
+		if err != nil {
+			if err == syscall.ESRCH && !roots[pid] {
+				continue
 			}
+			return signaled, errors.WithStack(err)
+		}
+		signaled = append(signaled, pid)
+		attack.PIDs = append(attack.PIDs, pid)
+		attack.StartTimes = append(attack.StartTimes, createTime)
+	}
+
+	return signaled, nil
+}
+
+// processCreateTime returns the create time of the process, or ESRCH if it no longer exists.
+func processCreateTime(pid int) (int64, error) {
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return 0, syscall.ESRCH
+	}
+	createTime, err := p.CreateTime()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, syscall.ESRCH
+		}
+		return 0, errors.WithStack(err)
+	}
+	return createTime, nil
+}
+
+type matchedProcess struct {
+	pid        int
+	createTime int64
+}
+
+// findProcesses returns the PIDs of the processes selected by the attack, ordered by the start time.
+func findProcesses(attack *core.ProcessCommand) ([]int, error) {
+	var re *regexp.Regexp
+	if attack.Regex {
+		var err error
+		if re, err = regexp.Compile(attack.Process); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	uid := int32(-1)
+	if len(attack.User) > 0 {
+		u, err := user.Lookup(attack.User)
+		if err != nil {
+			if u, err = user.LookupId(attack.User); err != nil {
+				return nil, errors.Wrapf(err, "user %s not found", attack.User)
+			}
+		}
+		id, err := strconv.Atoi(u.Uid)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		uid = int32(id)
+	}
+
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
 
+	protected := protectedProcesses()
+	var matched []matchedProcess
+	for _, pid := range pids {
+		if protected[int(pid)] {
+			continue
+		}
+		// the processes may exit at any time, so the ones can't be read are skipped
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+
+		if len(attack.Process) > 0 {
+			var target string
+			if attack.FullCmdline {
+				target, err = p.Cmdline()
+			} else {
+				target, err = p.Name()
+			}
 			if err != nil {
-				return errors.WithStack(err)
+				continue
+			}
+
+			if re != nil {
+				if !re.MatchString(target) {
+					continue
+				}
+			} else if attack.Process != strconv.Itoa(int(pid)) && attack.Process != target {
+				continue
 			}
-			attack.PIDs = append(attack.PIDs, p.Pid())
 		}
+
+		if uid >= 0 {
+			// the effective UID is the second one
+			uids, err := p.Uids()
+			if err != nil || len(uids) < 2 || uids[1] != uid {
+				continue
+			}
+		}
+
+		createTime, err := p.CreateTime()
+		if err != nil {
+			continue
+		}
+		matched = append(matched, matchedProcess{pid: int(pid), createTime: createTime})
 	}
 
-	if notFound {
-		err = errors.Errorf("process %s not found", attack.Process)
-		return errors.WithStack(err)
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].createTime == matched[j].createTime {
+			return matched[i].pid < matched[j].pid
+		}
+		return matched[i].createTime < matched[j].createTime
+	})
+
+	if len(matched) > 0 {
+		if attack.Newest {
+			matched = matched[len(matched)-1:]
+		} else if attack.Oldest {
+			matched = matched[:1]
+		}
+	}
+	if attack.MaxCount > 0 && len(matched) > attack.MaxCount {
+		matched = matched[:attack.MaxCount]
+	}
+
+	result := make([]int, 0, len(matched))
+	for _, p := range matched {
+		result = append(result, p.pid)
+	}
+	return result, nil
+}
+
+// protectedProcesses returns the init process, chaosd and the ancestors of chaosd, which are
+// never attacked, otherwise chaosd may be stopped or killed along with them.
+func protectedProcesses() map[int]bool {
+	protected := map[int]bool{1: true}
+	pid := int32(os.Getpid())
+	for pid > 0 && !protected[int(pid)] {
+		protected[int(pid)] = true
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			break
+		}
+		if pid, err = p.Ppid(); err != nil {
+			break
+		}
+	}
+	return protected
+}
+
+// withDescendants returns the processes followed by their descendants in the pre-order of
+// the process tree, the children of a process are ordered by PID. chaosd and its ancestors
+// are left out, they are descendants of the processes when chaosd is started by one of them.
+func withDescendants(pids []int) ([]int, error) {
+	all, err := process.Pids()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the edges are iterated in the reverse order of the insertion
+	sort.Slice(all, func(i, j int) bool { return all[i] > all[j] })
+	tree := utils.NewGraph()
+	for _, pid := range all {
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+		ppid, err := p.Ppid()
+		if err != nil || ppid == pid {
+			continue
+		}
+		tree.Insert(uint32(ppid), uint32(pid))
 	}
 
-	return nil
+	visited := protectedProcesses()
+	var result []int
+	for _, pid := range pids {
+		for _, p := range append([]uint32{uint32(pid)}, tree.Flatten(uint32(pid))...) {
+			if !visited[int(p)] {
+				visited[int(p)] = true
+				result = append(result, int(p))
+			}
+		}
+	}
+	return result, nil
 }
 
-func (processAttack) Recover(exp core.Experiment, _ Environment) error {
+func (processAttack) Recover(exp core.Experiment, env Environment) error {
 	pcmd := &core.ProcessCommand{}
 	if err := json.Unmarshal([]byte(exp.RecoverCommand), pcmd); err != nil {
 		return err
 	}
-	if pcmd.Signal != int(syscall.SIGSTOP) {
-		return core.ErrNonRecoverableAttack.New("only SIGSTOP process attack is supported to recover")
+	if pcmd.Action == core.ProcessFlapAction {
+		// the killed processes are restarted by others, only the flap is stopped
+		env.Chaos.stopFlap(exp.Uid)
+		return nil
+	}
+	if !pcmd.Recoverable() {
+		return core.ErrNonRecoverableAttack.New("process attack with %s is not recoverable, only SIGSTOP is supported to recover",
+			unix.SignalName(syscall.Signal(pcmd.Signal)))
 	}
 
-	for _, pid := range pcmd.PIDs {
-		if err := syscall.Kill(pid, syscall.SIGCONT); err != nil {
-			return errors.WithStack(err)
+	// resume the processes in the reverse order, so the children are resumed before their parents,
+	// the others are still resumed if some of them have exited or can't be resumed
+	var errs error
+	for i := len(pcmd.PIDs) - 1; i >= 0; i-- {
+		pid := pcmd.PIDs[i]
+		// the experiments created by earlier versions of chaosd don't record the start times
+		if i < len(pcmd.StartTimes) {
+			createTime, err := processCreateTime(pid)
+			if err == syscall.ESRCH || (err == nil && createTime != pcmd.StartTimes[i]) {
+				log.Warn("the stopped process no longer exists", zap.Int("pid", pid))
+				continue
+			}
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+		}
+
+		if err := syscall.Kill(pid, syscall.SIGCONT); err != nil && err != syscall.ESRCH {
+			errs = multierror.Append(errs, errors.Wrapf(err, "resume process %d", pid))
 		}
 	}
 
-	return nil
+	return errs
 }
@@ -0,0 +1,230 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// restartPollInterval is the interval of finding the restarted processes.
+const restartPollInterval = 100 * time.Millisecond
+
+// processFlap is a flap action running in the background of chaosd.
+type processFlap struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// flapProcesses kills the processes repeatedly in the background, until the times or the duration
+// of the attack runs out, or the experiment is recovered. Every kill is recorded as a run of the
+// experiment, which finishes when the killed processes are restarted, so the duration of the run
+// is the restart latency.
+func flapProcesses(attack *core.ProcessCommand, env Environment) error {
+	exp, err := env.Chaos.exp.FindByUid(context.Background(), env.AttackUid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	timeout, err := attack.RestartTimeoutDuration()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var deadline time.Time
+	duration, err := attack.ScheduleDuration()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if duration != nil {
+		deadline = time.Now().Add(*duration)
+	}
+
+	pids, err := findAttackedProcesses(attack)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// the recover data of the experiment is saved while the flap is running, so the flap works on a copy
+	flapped := *attack
+	flapped.PIDs = nil
+	flap := env.Chaos.startFlap(env.AttackUid)
+	go func() {
+		defer env.Chaos.finishFlap(env.AttackUid)
+		if err := runFlap(&flapped, env, exp.ID, pids, timeout, deadline, flap.stop); err != nil {
+			log.Error("failed to flap processes", zap.String("uid", env.AttackUid), zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+func runFlap(attack *core.ProcessCommand, env Environment, expID uint, pids []int,
+	timeout time.Duration, deadline time.Time, stop <-chan struct{}) error {
+	for i := 0; attack.Times == 0 || i < attack.Times; i++ {
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return nil
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		// the experiment may be recovered by another chaosd process
+		exp, err := env.Chaos.exp.FindByUid(context.Background(), env.AttackUid)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if exp.Status == core.Destroyed {
+			return nil
+		}
+
+		run := &core.ExperimentRun{
+			UID:          uuid.New().String(),
+			StartAt:      time.Now(),
+			Status:       core.Running,
+			ExperimentID: expID,
+		}
+		if err = env.Chaos.ExpRun.Set(context.Background(), run); err != nil {
+			return errors.WithStack(err)
+		}
+
+		killed, err := signalProcesses(attack, pids)
+		if err == nil {
+			pids, err = waitRestarted(attack, killed, timeout, stop)
+		}
+
+		status, msg := core.Success, ""
+		finishedAt := time.Now()
+		if err != nil {
+			status, msg = core.Error, err.Error()
+		} else {
+			msg = fmt.Sprintf("restarted as %v", pids)
+		}
+		if err := env.Chaos.ExpRun.Update(context.Background(), run.UID, status, msg, finishedAt); err != nil {
+			log.Error("failed to update experiment run", zap.String("uid", run.UID), zap.Error(err))
+		}
+		if err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			return errors.WithStack(err)
+		}
+		log.Info("processes are restarted", zap.String("uid", env.AttackUid), zap.Ints("pids", pids),
+			zap.Duration("latency", finishedAt.Sub(run.StartAt)))
+	}
+
+	return nil
+}
+
+func (s *Server) startFlap(uid string) *processFlap {
+	s.flapsMu.Lock()
+	defer s.flapsMu.Unlock()
+
+	flap := &processFlap{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	s.flaps[uid] = flap
+	return flap
+}
+
+func (s *Server) finishFlap(uid string) {
+	s.flapsMu.Lock()
+	defer s.flapsMu.Unlock()
+
+	if flap, ok := s.flaps[uid]; ok {
+		close(flap.done)
+		delete(s.flaps, uid)
+	}
+}
+
+// stopFlap stops the flap of the experiment and waits until its last kill is finished.
+// It returns immediately if the flap is not running in this chaosd process.
+func (s *Server) stopFlap(uid string) {
+	s.flapsMu.Lock()
+	flap, ok := s.flaps[uid]
+	if ok {
+		select {
+		case <-flap.stop:
+		default:
+			close(flap.stop)
+		}
+	}
+	s.flapsMu.Unlock()
+	if !ok {
+		return
+	}
+
+	<-flap.done
+}
+
+// WaitFlap blocks until the flap of the experiment is ended. It returns
+// immediately if the flap is not running in this chaosd process.
+func (s *Server) WaitFlap(uid string) {
+	s.flapsMu.Lock()
+	flap, ok := s.flaps[uid]
+	s.flapsMu.Unlock()
+	if !ok {
+		return
+	}
+
+	<-flap.done
+}
+
+// waitRestarted waits until the processes of the attack are found again, except the killed ones.
+// It gives up if the flap is stopped while waiting.
+func waitRestarted(attack *core.ProcessCommand, killed []int, timeout time.Duration, stop <-chan struct{}) ([]int, error) {
+	killedSet := make(map[int]bool)
+	for _, pid := range killed {
+		killedSet[pid] = true
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pids, err := findProcesses(attack)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var restarted []int
+		for _, pid := range pids {
+			if !killedSet[pid] {
+				restarted = append(restarted, pid)
+			}
+		}
+		if len(restarted) > 0 {
+			return restarted, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("process %s is not restarted in %s", attack.Process, timeout)
+		}
+		select {
+		case <-stop:
+			return nil, errors.Errorf("flap is stopped before process %s is restarted", attack.Process)
+		case <-time.After(restartPollInterval):
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+func TestFindProcessesSkipsProtected(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	protected := protectedProcesses()
+	g.Expect(protected).Should(HaveKey(1))
+	g.Expect(protected).Should(HaveKey(os.Getpid()))
+	g.Expect(protected).Should(HaveKey(os.Getppid()))
+
+	// all the processes of the user are selected without a process pattern
+	pids, err := findProcesses(&core.ProcessCommand{User: strconv.Itoa(os.Geteuid())})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	for _, pid := range pids {
+		g.Expect(protected).ShouldNot(HaveKey(pid))
+	}
+}
+
+func TestWithDescendantsSkipsProtected(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the parent of the test is an ancestor of chaosd, so itself and chaosd are left out
+	pids, err := withDescendants([]int{os.Getppid()})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(pids).ShouldNot(ContainElement(os.Getppid()))
+	g.Expect(pids).ShouldNot(ContainElement(os.Getpid()))
+}
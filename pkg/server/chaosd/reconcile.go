@@ -0,0 +1,491 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+const (
+	DriftKindIPSet    = "ipset"
+	DriftKindIptables = "iptables"
+	DriftKindTC       = "tc"
+	DriftKindIFB      = "ifb"
+	// DriftKindContainer is the container whose rules are stored but which no longer exists.
+	DriftKindContainer = "container"
+)
+
+// NetworkDrift is a difference between the network rules in the stores
+// and the ones installed in the kernel.
+type NetworkDrift struct {
+	// ContainerID is the container whose network namespace has the drift,
+	// it is empty for the host.
+	ContainerID string
+	Kind        string
+	// Name is the name of the ipset, iptables chain, or the device.
+	Name string
+	// Orphaned is true if the object is installed but not stored,
+	// otherwise it is stored but not installed.
+	Orphaned bool
+}
+
+// netNSRules are the stored network rules of a network namespace.
+type netNSRules struct {
+	ipsets   []*core.IPSetRule
+	iptables []*core.IptablesRule
+	tcs      []*core.TCRule
+}
+
+// Reconcile reports the drift between the network rules in the stores and the kernel
+// when chaosd server starts, and applies the stored rules which are not installed.
+func Reconcile(s *Server) error {
+	drifts, err := s.DiagnoseNetwork()
+	if err != nil {
+		log.Warn("failed to diagnose network rules", zap.Error(err))
+		return nil
+	}
+
+	missing := false
+	for _, drift := range drifts {
+		log.Warn("network rules drift", zap.String("container", drift.ContainerID),
+			zap.String("kind", drift.Kind), zap.String("name", drift.Name), zap.Bool("orphaned", drift.Orphaned))
+		missing = missing || (!drift.Orphaned && drift.Kind != DriftKindContainer)
+	}
+
+	if missing {
+		if err := s.ReapplyNetwork(); err != nil {
+			log.Error("failed to apply stored network rules", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// DiagnoseNetwork compares the network rules in the stores with the ones installed in the kernel.
+func (s *Server) DiagnoseNetwork() ([]NetworkDrift, error) {
+	namespaces, err := s.listNetNSRules()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var drifts []NetworkDrift
+	for _, containerID := range sortedNetNS(namespaces) {
+		if s.containerGone(containerID) {
+			drifts = append(drifts, NetworkDrift{ContainerID: containerID, Kind: DriftKindContainer, Name: containerID})
+			continue
+		}
+
+		ds, err := s.diagnoseNetNS(containerID, namespaces[containerID])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		drifts = append(drifts, ds...)
+	}
+
+	return drifts, nil
+}
+
+// ReapplyNetwork applies the stored network rules to the kernel again.
+func (s *Server) ReapplyNetwork() error {
+	namespaces, err := s.listNetNSRules()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, containerID := range sortedNetNS(namespaces) {
+		if s.containerGone(containerID) {
+			continue
+		}
+		if err := s.reapplyNetNS(containerID, namespaces[containerID]); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// RemoveNetworkOrphans removes the orphaned objects of the drifts from the kernel.
+func (s *Server) RemoveNetworkOrphans(drifts []NetworkDrift) error {
+	namespaces, err := s.listNetNSRules()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	orphans := make(map[string]map[string][]string)
+	for _, drift := range drifts {
+		if !drift.Orphaned {
+			continue
+		}
+		if orphans[drift.ContainerID] == nil {
+			orphans[drift.ContainerID] = make(map[string][]string)
+		}
+		orphans[drift.ContainerID][drift.Kind] = append(orphans[drift.ContainerID][drift.Kind], drift.Name)
+	}
+
+	for containerID, kinds := range orphans {
+		rules := namespaces[containerID]
+		if rules == nil {
+			rules = &netNSRules{}
+		}
+		if err := s.removeNetNSOrphans(containerID, rules, kinds); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// containerGone returns true if the network namespace of the container can't be entered,
+// such as the container is removed after its rules are stored.
+func (s *Server) containerGone(containerID string) bool {
+	if len(containerID) == 0 {
+		return false
+	}
+
+	if _, err := s.criCli.GetPidFromContainerID(context.Background(), containerID); err != nil {
+		log.Warn("failed to get the network namespace of the container", zap.String("container", containerID), zap.Error(err))
+		return true
+	}
+	return false
+}
+
+func (s *Server) listNetNSRules() (map[string]*netNSRules, error) {
+	ctx := context.Background()
+	namespaces := map[string]*netNSRules{"": {}}
+	get := func(containerID string) *netNSRules {
+		if namespaces[containerID] == nil {
+			namespaces[containerID] = &netNSRules{}
+		}
+		return namespaces[containerID]
+	}
+
+	ipsets, err := s.ipsetRule.List(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, rule := range ipsets {
+		rules := get(rule.ContainerID)
+		rules.ipsets = append(rules.ipsets, rule)
+	}
+
+	iptables, err := s.iptablesRule.List(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, rule := range iptables {
+		rules := get(rule.ContainerID)
+		rules.iptables = append(rules.iptables, rule)
+	}
+
+	tcs, err := s.tcRule.List(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, rule := range tcs {
+		rules := get(rule.ContainerID)
+		rules.tcs = append(rules.tcs, rule)
+	}
+
+	return namespaces, nil
+}
+
+func (s *Server) diagnoseNetNS(containerID string, rules *netNSRules) ([]NetworkDrift, error) {
+	var drifts []NetworkDrift
+	compare := func(kind string, stored map[string]bool, installed map[string]bool) {
+		for _, name := range sortedKeys(installed) {
+			if !stored[name] {
+				drifts = append(drifts, NetworkDrift{ContainerID: containerID, Kind: kind, Name: name, Orphaned: true})
+			}
+		}
+		for _, name := range sortedKeys(stored) {
+			if !installed[name] {
+				drifts = append(drifts, NetworkDrift{ContainerID: containerID, Kind: kind, Name: name})
+			}
+		}
+	}
+
+	storedIPSets := make(map[string]bool)
+	for _, rule := range rules.ipsets {
+		storedIPSets[rule.Name] = true
+	}
+	ipsets, err := s.listIPSets(containerID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// the stored ipsets may be created with another prefix by the former versions of chaosd
+	installedIPSets := make(map[string]bool)
+	for name := range ipsets {
+		if strings.HasPrefix(name, IPSetPrefix) || storedIPSets[name] {
+			installedIPSets[name] = true
+		}
+	}
+	compare(DriftKindIPSet, storedIPSets, installedIPSets)
+
+	storedChains := make(map[string]bool)
+	for _, rule := range rules.iptables {
+		storedChains[rule.Name] = true
+	}
+	installedChains, err := s.listPartitionChains(containerID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	compare(DriftKindIptables, storedChains, installedChains)
+
+	storedIFBs, storedTCs := make(map[string]bool), make(map[string]bool)
+	for _, rule := range rules.tcs {
+		if len(rule.IngressDevice) > 0 {
+			storedIFBs[rule.Device] = true
+		}
+		storedTCs[rule.Device] = true
+	}
+	devices, err := s.listDevices(containerID, "")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ifbs, err := s.listDevices(containerID, "ifb")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	installedIFBs, installedTCs := make(map[string]bool), make(map[string]bool)
+	for device := range ifbs {
+		if strings.HasPrefix(device, IFBPrefix) {
+			installedIFBs[device] = true
+		}
+	}
+	for device := range devices {
+		// the qdiscs can't be told from the ones of users, so only the qdiscs of the stored devices
+		// and the IFB devices created by chaosd are compared
+		if !storedTCs[device] && !strings.HasPrefix(device, IFBPrefix) {
+			continue
+		}
+		hasQdisc, err := s.hasChaosQdisc(containerID, device)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if hasQdisc {
+			installedTCs[device] = true
+		}
+	}
+	compare(DriftKindIFB, storedIFBs, installedIFBs)
+	compare(DriftKindTC, storedTCs, installedTCs)
+
+	return drifts, nil
+}
+
+func (s *Server) reapplyNetNS(containerID string, rules *netNSRules) error {
+	enterNS := len(containerID) > 0
+
+	if len(rules.ipsets) > 0 {
+		ipsets := make([]*pb.IPSet, 0, len(rules.ipsets))
+		for _, rule := range rules.ipsets {
+			ipsets = append(ipsets, &pb.IPSet{Name: rule.Name, Cidrs: strings.Split(rule.Cidrs, ",")})
+		}
+		if _, err := s.svr.FlushIPSets(context.Background(), &pb.IPSetsRequest{
+			Ipsets:      ipsets,
+			ContainerId: containerID,
+			EnterNS:     enterNS,
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
+		Chains:      core.IptablesRuleList(rules.iptables).ToChains(),
+		ContainerId: containerID,
+		EnterNS:     enterNS,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	ingressDevices := make(map[string]bool)
+	for _, rule := range rules.tcs {
+		if len(rule.IngressDevice) > 0 && !ingressDevices[rule.IngressDevice] {
+			ingressDevices[rule.IngressDevice] = true
+			if _, err := s.setupIFB(containerID, rule.IngressDevice); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	return errors.WithStack(s.reapplyTCs(containerID))
+}
+
+func (s *Server) removeNetNSOrphans(containerID string, rules *netNSRules, orphans map[string][]string) error {
+	// resetting the chains of the stored rules removes the references to the orphaned chains
+	if len(orphans[DriftKindIptables]) > 0 {
+		if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
+			Chains:      core.IptablesRuleList(rules.iptables).ToChains(),
+			ContainerId: containerID,
+			EnterNS:     len(containerID) > 0,
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for _, chain := range orphans[DriftKindIptables] {
+		if err := s.deleteChain(containerID, chain); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	for _, device := range orphans[DriftKindTC] {
+		if err := s.runInNetNS(containerID, "tc", []string{"qdisc", "del", "dev", device, "root"},
+			noSuchQdiscErr, invalidQdiscErr, noSuchDeviceErr); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if len(orphans[DriftKindIFB]) > 0 {
+		devices, err := s.listDevices(containerID, "")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		ingressDevices := make(map[string]string)
+		for device := range devices {
+			ingressDevices[ifbName(device)] = device
+		}
+		for _, ifb := range orphans[DriftKindIFB] {
+			if device, ok := ingressDevices[ifb]; ok {
+				err = s.teardownIFB(containerID, device)
+			} else {
+				err = s.runInNetNS(containerID, "ip", []string{"link", "del", ifb}, noSuchDeviceErr)
+			}
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	for _, ipset := range orphans[DriftKindIPSet] {
+		if err := s.runInNetNS(containerID, "ipset", []string{"destroy", ipset}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// listIPSets returns the ipsets in the network namespace.
+func (s *Server) listIPSets(containerID string) (map[string]bool, error) {
+	out, err := s.outputInNetNS(containerID, "ipset", []string{"list", "-n"})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parseIPSets(out), nil
+}
+
+// parseIPSets parses the output of "ipset list -n".
+func parseIPSets(out string) map[string]bool {
+	ipsets := make(map[string]bool)
+	for _, name := range strings.Fields(out) {
+		ipsets[name] = true
+	}
+	return ipsets
+}
+
+// listPartitionChains returns the iptables chains created by the partition attack.
+func (s *Server) listPartitionChains(containerID string) (map[string]bool, error) {
+	out, err := s.outputInNetNS(containerID, "iptables", []string{"-w", "-S"})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parsePartitionChains(out), nil
+}
+
+// parsePartitionChains parses the partition chains from the output of "iptables -S".
+func parsePartitionChains(out string) map[string]bool {
+	chains := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "-N" && core.IsPartitionChain(fields[1]) {
+			chains[fields[1]] = true
+		}
+	}
+	return chains
+}
+
+// listDevices returns the network devices of the type, or all devices if the type is empty.
+func (s *Server) listDevices(containerID string, typ string) (map[string]bool, error) {
+	args := []string{"-o", "link", "show"}
+	if len(typ) > 0 {
+		args = append(args, "type", typ)
+	}
+	out, err := s.outputInNetNS(containerID, "ip", args)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parseDevices(out), nil
+}
+
+// parseDevices parses the output of "ip -o link show".
+func parseDevices(out string) map[string]bool {
+	// such as: 2: eth0@if12: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 ...
+	devices := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		device := strings.SplitN(strings.TrimSuffix(fields[1], ":"), "@", 2)[0]
+		devices[device] = true
+	}
+	return devices
+}
+
+// hasChaosQdisc returns true if the device has the qdiscs used by the tc attacks.
+func (s *Server) hasChaosQdisc(containerID string, device string) (bool, error) {
+	out, err := s.outputInNetNS(containerID, "tc", []string{"qdisc", "show", "dev", device})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return parseChaosQdisc(out), nil
+}
+
+// parseChaosQdisc returns true if the output of "tc qdisc show" has the qdiscs used by the tc attacks.
+func parseChaosQdisc(out string) bool {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[0] == "qdisc" && (fields[1] == "netem" || fields[1] == "tbf") {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNetNS(namespaces map[string]*netNSRules) []string {
+	ids := make([]string, 0, len(namespaces))
+	for id := range namespaces {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseIPSets(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		out  string
+		want map[string]bool
+	}{
+		{"", map[string]bool{}},
+		{"chaosd-93e7ae10-0c13-4d\nKUBE-CLUSTER-IP\n", map[string]bool{"chaosd-93e7ae10-0c13-4d": true, "KUBE-CLUSTER-IP": true}},
+	}
+	for _, tt := range tests {
+		g.Expect(parseIPSets(tt.out)).Should(Equal(tt.want))
+	}
+}
+
+func TestParsePartitionChains(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		out  string
+		want map[string]bool
+	}{
+		{"-P INPUT ACCEPT\n-P OUTPUT ACCEPT\n", map[string]bool{}},
+		{
+			"-P INPUT ACCEPT\n-N chaos-in-0123456789abcdef\n-N KUBE-SERVICES\n" +
+				"-A INPUT -j chaos-in-0123456789abcdef\n-A chaos-in-0123456789abcdef -j DROP\n",
+			map[string]bool{"chaos-in-0123456789abcdef": true},
+		},
+	}
+	for _, tt := range tests {
+		g.Expect(parsePartitionChains(tt.out)).Should(Equal(tt.want))
+	}
+}
+
+func TestParseDevices(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		out  string
+		want map[string]bool
+	}{
+		{"", map[string]bool{}},
+		{
+			"1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN\\    link/loopback 00:00:00:00:00:00\n" +
+				"2: eth0@if12: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP\\    link/ether 02:42:ac:11:00:02\n",
+			map[string]bool{"lo": true, "eth0": true},
+		},
+	}
+	for _, tt := range tests {
+		g.Expect(parseDevices(tt.out)).Should(Equal(tt.want))
+	}
+}
+
+func TestParseChaosQdisc(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		out  string
+		want bool
+	}{
+		{"qdisc noqueue 0: root refcnt 2\n", false},
+		{"qdisc fq_codel 0: root refcnt 2 limit 10240p flows 1024\n", false},
+		{"qdisc netem 8001: root refcnt 2 limit 1000 delay 10.0ms\n", true},
+		{"qdisc tbf 8002: root refcnt 2 rate 1Mbit burst 10000b lat 50.0ms\n", true},
+	}
+	for _, tt := range tests {
+		g.Expect(parseChaosQdisc(tt.out)).Should(Equal(tt.want))
+	}
+}
@@ -38,39 +38,30 @@ func (s *Server) RecoverAttack(uid string) error {
 		return perr.Errorf("can not recover %s experiment", exp.Status)
 	}
 
-	if len(exp.Cron) > 0 {
+	attackType, _, err := attackOfKind(exp.Kind)
+	if err != nil {
+		return err
+	}
+
+	var message string
+	// the runs of a schedule are recovered by removing it, schedules which are
+	// not registered here, e.g. failed to be restored, are recovered directly
+	if len(exp.Cron) > 0 && s.Cron.Scheduled(exp.ID) {
 		if err = s.Cron.Remove(exp.ID); err != nil {
 			return perr.WithMessage(err, "failed to remove scheduled task")
 		}
-	}
-
-	var attackType AttackType
-	switch exp.Kind {
-	case core.ProcessAttack:
-		attackType = ProcessAttack
-	case core.NetworkAttack:
-		attackType = NetworkAttack
-	case core.HostAttack:
-		attackType = HostAttack
-	case core.StressAttack:
-		attackType = StressAttack
-	case core.DiskAttack:
-		attackType = DiskAttack
-	default:
-		return perr.Errorf("chaos experiment kind %s not found", exp.Kind)
-	}
-
-	env := s.newEnvironment(uid)
-	if err = attackType.Recover(*exp, env); err != nil {
-		if errorx.IsOfType(err, core.ErrNonRecoverableAttack) {
-			log.Warn(err.Error(), zap.String("uid", uid), zap.String("kind", exp.Kind))
-			return nil
+	} else if err = attackType.Recover(*exp, s.newEnvironment(uid)); err != nil {
+		if !errorx.IsOfType(err, core.ErrNonRecoverableAttack) && !errorx.IsOfType(err, core.ErrAlreadyRecovered) {
+			return perr.WithMessagef(err, "Recover experiment %s failed", uid)
 		}
-		return perr.WithMessagef(err, "Recover experiment %s failed", uid)
+		// the experiment is ended, and the reason why it is not recovered by chaosd is kept in the record
+		log.Warn(err.Error(), zap.String("uid", uid), zap.String("kind", exp.Kind))
+		message = err.Error()
 	}
 
-	if err := s.exp.Update(context.Background(), uid, core.Destroyed, "", exp.RecoverCommand); err != nil {
+	if err := s.exp.Update(context.Background(), uid, core.Destroyed, message, exp.RecoverCommand); err != nil {
 		return perr.WithStack(err)
 	}
+	s.finishRecover(uid, nil)
 	return nil
 }
@@ -0,0 +1,86 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pingcap/log"
+	perr "github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// Restore brings back the in-memory state of the experiments saved in the
+// experiment store, it should be called once when the chaosd server starts.
+func Restore(s *Server) error {
+	for _, status := range []string{core.Success, core.Scheduled} {
+		exps, err := s.exp.ListByStatus(context.Background(), status)
+		if err != nil {
+			return perr.WithStack(err)
+		}
+
+		for _, exp := range exps {
+			if len(exp.Cron) > 0 {
+				if err := s.restoreSchedule(exp); err != nil {
+					// keep it scheduled so that it can still be recovered
+					log.Error("failed to restore scheduled experiment", zap.String("uid", exp.Uid), zap.Error(err))
+					msg := fmt.Sprintf("failed to restore schedule: %s", err)
+					if err := s.exp.Update(context.Background(), exp.Uid, core.Scheduled, msg, exp.RecoverCommand); err != nil {
+						log.Error("failed to update experiment", zap.Error(err))
+					}
+					continue
+				}
+			}
+
+			if exp.RecoverAt != nil {
+				s.scheduleRecover(exp.Uid, *exp.RecoverAt)
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreSchedule rebuilds the attack config of a scheduled experiment
+// from its recover data and registers it with the scheduler again.
+func (s *Server) restoreSchedule(exp *core.Experiment) error {
+	attackType, options, err := attackOfKind(exp.Kind)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(exp.RecoverCommand), options); err != nil {
+		return perr.WithStack(err)
+	}
+
+	if err := options.Validate(); err != nil {
+		return perr.WithStack(err)
+	}
+
+	task := cronAttack{
+		attackType: attackType,
+		options:    options,
+		env:        s.newEnvironment(exp.Uid),
+	}
+	if err := s.Cron.Restore(*exp, exp.Cron, task); err != nil {
+		return perr.WithStack(err)
+	}
+
+	log.Info("restore scheduled experiment", zap.String("uid", exp.Uid), zap.String("cron", exp.Cron))
+	return nil
+}
@@ -38,3 +38,16 @@ func (s *Server) Search(conds *core.SearchCommand) ([]*core.Experiment, error) {
 
 	return exps, nil
 }
+
+func (s *Server) SearchRuns(uid string) ([]*core.ExperimentRun, error) {
+	if _, err := s.exp.FindByUid(context.Background(), uid); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	runs, err := s.ExpRun.ListByExperimentUID(context.Background(), uid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return runs, nil
+}
@@ -14,9 +14,12 @@
 package chaosd
 
 import (
+	"sync"
+
 	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon"
 
 	"github.com/chaos-mesh/chaosd/pkg/config"
+	"github.com/chaos-mesh/chaosd/pkg/container"
 	"github.com/chaos-mesh/chaosd/pkg/core"
 	"github.com/chaos-mesh/chaosd/pkg/scheduler"
 )
@@ -30,6 +33,13 @@ type Server struct {
 	tcRule       core.TCRuleStore
 	conf         *config.Config
 	svr          *chaosdaemon.DaemonServer
+	criCli       container.CRIClient
+
+	recoverTimersMu sync.Mutex
+	recoverTimers   map[string]*recoverTimer
+
+	flapsMu sync.Mutex
+	flaps   map[string]*processFlap
 }
 
 func NewServer(
@@ -40,6 +50,7 @@ func NewServer(
 	iptables core.IptablesRuleStore,
 	tc core.TCRuleStore,
 	svr *chaosdaemon.DaemonServer,
+	criCli container.CRIClient,
 	cron scheduler.Scheduler,
 ) *Server {
 	return &Server{
@@ -51,5 +62,9 @@ func NewServer(
 		iptablesRule: iptables,
 		tcRule:       tc,
 		svr:          svr,
+		criCli:       criCli,
+
+		recoverTimers: make(map[string]*recoverTimer),
+		flaps:         make(map[string]*processFlap),
 	}
 }
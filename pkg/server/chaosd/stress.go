@@ -14,29 +14,131 @@
 package chaosd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/chaos-mesh/chaos-mesh/api/v1alpha1"
 	"github.com/chaos-mesh/chaos-mesh/pkg/bpm"
 	"github.com/pingcap/log"
+	perr "github.com/pkg/errors"
 	"github.com/shirou/gopsutil/process"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
 type stressAttack struct{}
 
 var StressAttack AttackType = stressAttack{}
 
-func (stressAttack) Attack(options core.AttackConfig, _ Environment) (err error) {
+const (
+	cgroupProcsEnv = "CHAOSD_CGROUP_PROCS"
+	confineScript  = `IFS=:; for procs in $` + cgroupProcsEnv + `; do echo $$ > "$procs" || exit 1; done; exec "$@"`
+
+	// LoadProfileCommand is the hidden subcommand of "chaosd attack stress" which generates
+	// the load following a profile, it is run in the background instead of stress-ng.
+	LoadProfileCommand = "load-profile"
+)
+
+func (stressAttack) Attack(options core.AttackConfig, env Environment) (err error) {
 	attack := options.(*core.StressCommand)
+	name, args, err := stressCommand(attack)
+	if err != nil {
+		return
+	}
+
+	cgroupProcs, err := stressCGroupProcs(attack, env)
+	if err != nil {
+		return
+	}
+
+	var cmd *bpm.ManagedProcess
+	if len(cgroupProcs) > 0 {
+		// the shell moves itself into the cgroups before it is replaced by stress-ng,
+		// so all the workers forked by stress-ng are confined to the cgroups as well
+		cmd = bpm.DefaultProcessBuilder("sh", append([]string{"-c", confineScript, "sh", name}, args...)...).
+			Build()
+		cmd.Env = append(os.Environ(), cgroupProcsEnv+"="+strings.Join(cgroupProcs, ":"))
+		log.Info("confine stress-ng to cgroups", zap.Strings("cgroups", cgroupProcs))
+	} else {
+		cmd = bpm.DefaultProcessBuilder(name, args...).
+			Build()
+	}
+
+	// Build will set SysProcAttr.Pdeathsig = syscall.SIGTERM, and so stress-ng will exit while chaosd exit
+	// so reset it here. stress-ng leads a new process group, so that its workers are killed along with it.
+	cmd.Cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	backgroundProcessManager := bpm.NewBackgroundProcessManager()
+	err = backgroundProcessManager.StartProcess(cmd)
+	if err != nil {
+		return
+	}
+
+	attack.StressngPid = int32(cmd.Process.Pid)
+	attack.StressngPgid = int32(cmd.Process.Pid)
+	proc, err := process.NewProcess(attack.StressngPid)
+	if err != nil {
+		return
+	}
+	if attack.StressngStartTime, err = proc.CreateTime(); err != nil {
+		return
+	}
+	log.Info("Start stress-ng process successfully", zap.String("command", cmd.String()), zap.Int32("Pid", attack.StressngPid))
+
+	return nil
+}
+
+// stressCommand returns the command which generates the stress, it is stress-ng
+// unless the load follows a profile.
+func stressCommand(attack *core.StressCommand) (string, []string, error) {
+	if len(attack.Profile) == 0 {
+		args, err := stressngArgs(attack)
+		if err != nil {
+			return "", nil, err
+		}
+		log.Info("stressors normalize", zap.Strings("arguments", args))
+		return "stress-ng", args, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", nil, perr.WithStack(err)
+	}
+	args := []string{"attack", "stress", LoadProfileCommand,
+		"--action", attack.Action, "--workers", strconv.Itoa(attack.Workers)}
+	for _, segment := range attack.Profile {
+		args = append(args, "--profile", segment)
+	}
+	return exe, args, nil
+}
+
+// stressProcessName returns the name of the process which generates the stress.
+func stressProcessName(attack *core.StressCommand) string {
+	if len(attack.Profile) == 0 {
+		return "stress-ng"
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "chaosd"
+	}
+	return filepath.Base(exe)
+}
+
+// stressngArgs returns the stress-ng arguments of the stress attack.
+func stressngArgs(attack *core.StressCommand) ([]string, error) {
 	stressors := &v1alpha1.Stressors{}
-	if attack.Action == core.StressCPUAction {
+	switch attack.Action {
+	case core.StressCPUAction:
 		stressors.CPUStressor = &v1alpha1.CPUStressor{
 			Stressor: v1alpha1.Stressor{
 				Workers: attack.Workers,
@@ -44,7 +146,7 @@ func (stressAttack) Attack(options core.AttackConfig, _ Environment) (err error)
 			Load:    &attack.Load,
 			Options: attack.Options,
 		}
-	} else if attack.Action == core.StressMemAction {
+	case core.StressMemAction:
 		stressors.MemoryStressor = &v1alpha1.MemoryStressor{
 			Stressor: v1alpha1.Stressor{
 				Workers: attack.Workers,
@@ -52,36 +154,65 @@ func (stressAttack) Attack(options core.AttackConfig, _ Environment) (err error)
 			Size:    attack.Size,
 			Options: attack.Options,
 		}
+	case core.StressCustomAction:
+		if err := checkStressors(attack.Stressors); err != nil {
+			return nil, err
+		}
+		return attack.StressorArgs()
+	default:
+		return attack.StressorArgs()
 	}
 
 	errs := stressors.Validate(field.NewPath("stressors"))
 	if len(errs) > 0 {
-		return errors.New(errs.ToAggregate().Error())
+		return nil, errors.New(errs.ToAggregate().Error())
 	}
 
 	stressorsStr, err := stressors.Normalize()
 	if err != nil {
-		return
+		return nil, err
 	}
-	log.Info("stressors normalize", zap.String("arguments", stressorsStr))
+	return strings.Fields(stressorsStr), nil
+}
 
-	cmd := bpm.DefaultProcessBuilder("stress-ng", strings.Fields(stressorsStr)...).
-		Build()
+// checkStressors checks whether the stressors are supported by the installed stress-ng.
+func checkStressors(stressors []string) error {
+	out, err := bpm.DefaultProcessBuilder("stress-ng", "--stressors").Build().Output()
+	if err != nil {
+		return perr.Wrap(err, "list the stressors of stress-ng")
+	}
 
-	// Build will set SysProcAttr.Pdeathsig = syscall.SIGTERM, and so stress-ng will exit while chaosd exit
-	// so reset it here
-	cmd.Cmd.SysProcAttr = &syscall.SysProcAttr{}
+	supported := make(map[string]struct{})
+	for _, name := range strings.Fields(string(out)) {
+		supported[name] = struct{}{}
+	}
+	for _, stressor := range stressors {
+		name, _, err := core.ParseStressor(stressor)
+		if err != nil {
+			return err
+		}
+		if _, ok := supported[name]; !ok {
+			return perr.Errorf("stressor %s not supported by stress-ng", name)
+		}
+	}
+	return nil
+}
 
-	backgroundProcessManager := bpm.NewBackgroundProcessManager()
-	err = backgroundProcessManager.StartProcess(cmd)
-	if err != nil {
-		return
+// stressCGroupProcs returns the cgroup.procs files of the cgroups which the stress-ng process is confined to.
+func stressCGroupProcs(attack *core.StressCommand, env Environment) ([]string, error) {
+	if len(attack.CGroup) > 0 {
+		return utils.CGroupProcsOfPath(attack.CGroup)
 	}
 
-	attack.StressngPid = int32(cmd.Process.Pid)
-	log.Info("Start stress-ng process successfully", zap.String("command", cmd.String()), zap.Int32("Pid", attack.StressngPid))
+	if len(attack.ContainerID) > 0 {
+		pid, err := env.Chaos.criCli.GetPidFromContainerID(context.Background(), attack.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		return utils.CGroupProcsOfPid(int(pid))
+	}
 
-	return nil
+	return nil, nil
 }
 
 func (stressAttack) Recover(exp core.Experiment, _ Environment) error {
@@ -89,18 +220,50 @@ func (stressAttack) Recover(exp core.Experiment, _ Environment) error {
 	if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
 		return err
 	}
-	proc, err := process.NewProcess(attack.StressngPid)
+	if attack.StressngPid == 0 {
+		// a scheduled attack which has never been run has nothing to recover
+		return nil
+	}
+
+	// the experiments created by earlier versions of chaosd only recorded the pid
+	if attack.StressngStartTime == 0 {
+		proc, err := process.NewProcess(attack.StressngPid)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return core.ErrAlreadyRecovered.New("the stress-ng process %d no longer exists", attack.StressngPid)
+			}
+			return err
+		}
+		return killStressByName(proc, attack)
+	}
+
+	running, err := backgroundRunning(attack.StressngPid, attack.StressngStartTime)
 	if err != nil {
 		return err
 	}
+	if !running {
+		return core.ErrAlreadyRecovered.New("the stress-ng process %d has exited", attack.StressngPid)
+	}
+
+	// stress-ng is terminated gracefully first, so that the workers clean up their temporary files
+	if err := stopBackground(attack.StressngPid, attack.StressngStartTime); err != nil {
+		log.Error("the stress-ng process group stop failed", zap.Int32("pid", attack.StressngPid), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
 
+// killStressByName kills the stress process if its name is as expected.
+func killStressByName(proc *process.Process, attack *core.StressCommand) error {
 	procName, err := proc.Name()
 	if err != nil {
 		return err
 	}
 
-	if !strings.Contains(procName, "stress-ng") {
-		log.Warn("the process is not stress-ng, maybe it is killed by manual")
+	// the process name is truncated to 15 characters
+	if expected := stressProcessName(attack); len(procName) == 0 || !strings.HasPrefix(expected, procName) {
+		log.Warn("the process is not "+expected+", maybe it is killed by manual", zap.String("name", procName))
 		return nil
 	}
 
@@ -15,9 +15,13 @@ package httpserver
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
 )
 
 func (s *httpServer) listExperiments(c *gin.Context) {
@@ -28,3 +32,25 @@ func (s *httpServer) listExperiments(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, chaosList)
 }
+
+// @Summary List the runs of a scheduled experiment.
+// @Description List the runs of a scheduled experiment.
+// @Tags experiments
+// @Produce json
+// @Param uid path string true "uid"
+// @Success 200 {array} core.ExperimentRun
+// @Failure 404 {object} utils.APIError
+// @Failure 500 {object} utils.APIError
+// @Router /api/experiments/{uid}/runs [get]
+func (s *httpServer) listExperimentRuns(c *gin.Context) {
+	runs, err := s.chaos.SearchRuns(c.Param("uid"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithError(http.StatusNotFound, utils.ErrNotFound.WrapWithNoMessage(err))
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
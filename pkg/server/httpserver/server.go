@@ -14,7 +14,9 @@
 package httpserver
 
 import (
+	"encoding/json"
 	"net/http"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joomcode/errorx"
@@ -80,6 +82,7 @@ func handler(s *httpServer) {
 		attack.POST("/stress", s.createStressAttack)
 		attack.POST("/network", s.createNetworkAttack)
 		attack.POST("/disk", s.createDiskAttack)
+		attack.POST("/container", s.createContainerAttack)
 
 		attack.DELETE("/:uid", s.recoverAttack)
 	}
@@ -87,6 +90,7 @@ func handler(s *httpServer) {
 	experiments := api.Group("/experiments")
 	{
 		experiments.GET("/", s.listExperiments)
+		experiments.GET("/:uid/runs", s.listExperimentRuns)
 	}
 
 	system := api.Group("/system")
@@ -96,6 +100,30 @@ func handler(s *httpServer) {
 	}
 }
 
+// processAttackRequest is the body of the process attack, whose signal is given
+// by its number or its name like on the command line.
+type processAttackRequest struct {
+	*core.ProcessCommand
+	Signal json.RawMessage
+}
+
+// signal parses the signal of the request, a missing one sends SIGKILL,
+// or SIGSTOP for the stop action, as the command line does.
+func (r *processAttackRequest) signal() (int, error) {
+	if len(r.Signal) == 0 || string(r.Signal) == "null" {
+		if r.Action == core.ProcessStopAction {
+			return int(syscall.SIGSTOP), nil
+		}
+		return int(syscall.SIGKILL), nil
+	}
+
+	var name string
+	if err := json.Unmarshal(r.Signal, &name); err != nil {
+		name = string(r.Signal)
+	}
+	return core.ParseSignal(name)
+}
+
 // @Summary Create process attack.
 // @Description Create process attack.
 // @Tags attack
@@ -111,10 +139,18 @@ func (s *httpServer) createProcessAttack(c *gin.Context) {
 			Kind: core.ProcessAttack,
 		},
 	}
-	if err := c.ShouldBindJSON(attack); err != nil {
+	req := &processAttackRequest{ProcessCommand: attack}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	signal, err := req.signal()
+	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, utils.ErrInternalServer.WrapWithNoMessage(err))
 		return
 	}
+	attack.Signal = signal
 
 	uid, err := s.chaos.ExecuteAttack(chaosd.ProcessAttack, attack)
 	if err != nil {
@@ -137,7 +173,7 @@ func (s *httpServer) createProcessAttack(c *gin.Context) {
 func (s *httpServer) createNetworkAttack(c *gin.Context) {
 	attack := &core.NetworkCommand{
 		CommonAttackConfig: core.CommonAttackConfig{
-			Kind: core.ProcessAttack,
+			Kind: core.NetworkAttack,
 		},
 	}
 	if err := c.ShouldBindJSON(attack); err != nil {
@@ -145,6 +181,8 @@ func (s *httpServer) createNetworkAttack(c *gin.Context) {
 		return
 	}
 
+	attack.CompleteDefaults()
+
 	uid, err := s.chaos.ExecuteAttack(chaosd.NetworkAttack, attack)
 	if err != nil {
 		handleError(c, err)
@@ -166,7 +204,7 @@ func (s *httpServer) createNetworkAttack(c *gin.Context) {
 func (s *httpServer) createStressAttack(c *gin.Context) {
 	attack := &core.StressCommand{
 		CommonAttackConfig: core.CommonAttackConfig{
-			Kind: core.ProcessAttack,
+			Kind: core.StressAttack,
 		},
 	}
 	if err := c.ShouldBindJSON(attack); err != nil {
@@ -195,13 +233,17 @@ func (s *httpServer) createStressAttack(c *gin.Context) {
 func (s *httpServer) createDiskAttack(c *gin.Context) {
 	attack := &core.DiskOption{
 		CommonAttackConfig: core.CommonAttackConfig{
-			Kind: core.ProcessAttack,
+			Kind: core.DiskAttack,
 		},
 	}
 	if err := c.ShouldBindJSON(attack); err != nil {
 		c.AbortWithError(http.StatusBadRequest, utils.ErrInternalServer.WrapWithNoMessage(err))
 		return
 	}
+	if err := attack.ValidateOwnedFields(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
 
 	uid, err := s.chaos.ExecuteAttack(chaosd.DiskAttack, attack)
 
@@ -213,6 +255,31 @@ func (s *httpServer) createDiskAttack(c *gin.Context) {
 	c.JSON(http.StatusOK, utils.AttackSuccessResponse(uid))
 }
 
+// @Summary Create container attack.
+// @Description Create container attack.
+// @Tags attack
+// @Produce json
+// @Param request body core.ContainerCommand true "Request body"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.APIError
+// @Failure 500 {object} utils.APIError
+// @Router /api/attack/container [post]
+func (s *httpServer) createContainerAttack(c *gin.Context) {
+	attack := core.NewContainerCommand()
+	if err := c.ShouldBindJSON(attack); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	uid, err := s.chaos.ExecuteAttack(chaosd.ContainerAttack, attack)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.AttackSuccessResponse(uid))
+}
+
 // @Summary Create recover attack.
 // @Description Create recover attack.
 // @Tags attack
@@ -31,6 +31,8 @@ var Module = fx.Options(
 		chaosd.NewServer,
 		httpserver.NewServer,
 		crclient.NewNodeCRClient,
+		crclient.NewContainerRuntimeInfoClient,
+		crclient.NewCRIClient,
 		os.Getpid,
 		chaosdaemon.NewDaemonServerWithCRClient,
 		scheduler.NewScheduler,
@@ -16,6 +16,7 @@ package experiment
 import (
 	"context"
 	"errors"
+	"time"
 
 	perr "github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -50,9 +51,10 @@ func (store *experimentRunStore) ListByExperimentID(ctx context.Context, id uint
 func (store *experimentRunStore) ListByExperimentUID(ctx context.Context, uid string) ([]*core.ExperimentRun, error) {
 	runs := make([]*core.ExperimentRun, 0)
 	if err := store.db.
-		Joins("JOIN experiments ON experiments.uid = ?", uid).
-		Find(&runs).
+		Joins("JOIN experiments ON experiments.id = experiment_runs.experiment_id").
+		Where("experiments.uid = ?", uid).
 		Order("start_at DESC").
+		Find(&runs).
 		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, perr.WithStack(err)
 	}
@@ -72,3 +74,15 @@ func (store *experimentRunStore) LatestRun(ctx context.Context, id uint) (*core.
 
 	return run, nil
 }
+
+func (store *experimentRunStore) Set(_ context.Context, run *core.ExperimentRun) error {
+	return store.db.Model(core.ExperimentRun{}).Omit("Experiment").Save(run).Error
+}
+
+func (store *experimentRunStore) Update(_ context.Context, uid, status, msg string, finishedAt time.Time) error {
+	return store.db.
+		Model(core.ExperimentRun{}).
+		Where("uid = ?", uid).
+		Updates(core.ExperimentRun{Status: status, Message: msg, FinishedAt: finishedAt}).
+		Error
+}
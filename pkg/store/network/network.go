@@ -25,8 +25,15 @@ import (
 	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
 )
 
+// backfillContainerID sets the container id of the rules stored before the column was added,
+// they are applied on the host and are queried by the empty container id.
+func backfillContainerID(db *dbstore.DB, rule interface{}) {
+	db.Model(rule).Where("container_id IS NULL").Update("container_id", "")
+}
+
 func NewIPSetRuleStore(db *dbstore.DB) core.IPSetRuleStore {
 	db.AutoMigrate(&core.IPSetRule{})
+	backfillContainerID(db, &core.IPSetRule{})
 
 	is := &ipsetRuleStore{db}
 
@@ -75,6 +82,7 @@ func (i *ipsetRuleStore) DeleteByExperiment(_ context.Context, experiment string
 
 func NewIptablesRuleStore(db *dbstore.DB) core.IptablesRuleStore {
 	db.AutoMigrate(&core.IptablesRule{})
+	backfillContainerID(db, &core.IptablesRule{})
 
 	is := &iptablesRuleStore{db}
 
@@ -112,6 +120,18 @@ func (i *iptablesRuleStore) FindByExperiment(_ context.Context, experiment strin
 	return rules, nil
 }
 
+func (i *iptablesRuleStore) FindByContainer(_ context.Context, containerID string) ([]*core.IptablesRule, error) {
+	rules := make([]*core.IptablesRule, 0)
+	if err := i.db.
+		Where("container_id = ?", containerID).
+		Find(&rules).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return rules, nil
+}
+
 func (i *iptablesRuleStore) DeleteByExperiment(_ context.Context, experiment string) error {
 	return i.db.
 		Where("experiment = ?", experiment).
@@ -122,6 +142,7 @@ func (i *iptablesRuleStore) DeleteByExperiment(_ context.Context, experiment str
 
 func NewTCRuleStore(db *dbstore.DB) core.TCRuleStore {
 	db.AutoMigrate(&core.TCRule{})
+	backfillContainerID(db, &core.TCRule{})
 
 	ts := &tcRuleStore{db}
 
@@ -147,10 +168,10 @@ func (t *tcRuleStore) List(_ context.Context) ([]*core.TCRule, error) {
 	return rules, nil
 }
 
-func (t *tcRuleStore) FindByDevice(_ context.Context, device string) ([]*core.TCRule, error) {
+func (t *tcRuleStore) FindByDevice(_ context.Context, containerID string, device string) ([]*core.TCRule, error) {
 	rules := make([]*core.TCRule, 0)
 	if err := t.db.
-		Where("device = ?", device).
+		Where("container_id = ? AND device = ?", containerID, device).
 		Find(&rules).
 		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, perr.WithStack(err)
@@ -177,11 +198,12 @@ func (t *tcRuleStore) DeleteByExperiment(_ context.Context, experiment string) e
 		Error
 }
 
-func (t *tcRuleStore) ListGroupDevice(ctx context.Context) (map[string][]*core.TCRule, error) {
+func (t *tcRuleStore) ListGroupDevice(ctx context.Context, containerID string) (map[string][]*core.TCRule, error) {
 	rules := make(map[string][]*core.TCRule)
 	devices := []string{}
 	if err := t.db.
 		Model(&core.TCRule{}).
+		Where("container_id = ?", containerID).
 		Select("device").
 		Group("device").
 		Find(&devices).
@@ -190,7 +212,7 @@ func (t *tcRuleStore) ListGroupDevice(ctx context.Context) (map[string][]*core.T
 	}
 
 	for _, device := range devices {
-		rs, err := t.FindByDevice(ctx, device)
+		rs, err := t.FindByDevice(ctx, containerID, device)
 		if err != nil {
 			return nil, perr.WithStack(err)
 		}
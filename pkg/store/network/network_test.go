@@ -0,0 +1,56 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
+)
+
+func TestBackfillContainerID(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gormDB, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	db := &dbstore.DB{DB: gormDB}
+
+	// the rules of the host stored before the container_id column was added
+	g.Expect(db.Exec("CREATE TABLE tc_rules (id integer PRIMARY KEY, created_at datetime, updated_at datetime, " +
+		"deleted_at datetime, device text, type text, tc text, ip_set text, experiment text)").Error).Should(Succeed())
+	g.Expect(db.Exec("INSERT INTO tc_rules (id, device, type, experiment) VALUES (1, 'eth0', 'netem', 'exp')").Error).Should(Succeed())
+	g.Expect(db.Exec("CREATE TABLE iptables_rules (id integer PRIMARY KEY, created_at datetime, updated_at datetime, " +
+		"deleted_at datetime, name text, ip_sets text, direction text, experiment text)").Error).Should(Succeed())
+	g.Expect(db.Exec("INSERT INTO iptables_rules (id, name, experiment) VALUES (1, 'chaos', 'exp')").Error).Should(Succeed())
+
+	tcStore := NewTCRuleStore(db)
+	rules, err := tcStore.FindByDevice(context.Background(), "", "eth0")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(rules).Should(HaveLen(1))
+	devices, err := tcStore.ListGroupDevice(context.Background(), "")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(devices).Should(HaveKey("eth0"))
+
+	iptablesRules, err := NewIptablesRuleStore(db).FindByContainer(context.Background(), "")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(iptablesRules).Should(HaveLen(1))
+}
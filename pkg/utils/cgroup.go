@@ -0,0 +1,179 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+const (
+	mountInfoPath = "/proc/self/mountinfo"
+	cgroupProcs   = "cgroup.procs"
+)
+
+// cgroupMount is a mounted cgroup hierarchy, the controllers are empty for cgroup v2.
+type cgroupMount struct {
+	mountPoint  string
+	root        string
+	controllers []string
+	v2          bool
+}
+
+// CGroupProcsOfPid returns the cgroup.procs files of all cgroups the process belongs to,
+// writing a PID into them moves the process into the same cgroups.
+func CGroupProcsOfPid(pid int) ([]string, error) {
+	mounts, err := readCGroupMounts(mountInfoPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	return cgroupProcsOfPid(mounts, f)
+}
+
+// CGroupProcsOfPath returns the cgroup.procs files of the cgroup path, such as /kubepods/pod1/xxx,
+// in all mounted cgroup hierarchies which have this cgroup.
+func CGroupProcsOfPath(path string) ([]string, error) {
+	mounts, err := readCGroupMounts(mountInfoPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var files []string
+	for _, m := range mounts {
+		file := filepath.Join(m.mountPoint, relativeCGroupPath(m.root, path), cgroupProcs)
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("cgroup %s not found", path)
+	}
+	return files, nil
+}
+
+// cgroupProcsOfPid parses the content of /proc/<pid>/cgroup, whose lines are like
+// "4:memory:/kubepods/pod1/xxx", or "0::/system.slice/xxx.service" for cgroup v2.
+func cgroupProcsOfPid(mounts []cgroupMount, r io.Reader) ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		var controllers []string
+		if len(parts[1]) > 0 {
+			controllers = strings.Split(parts[1], ",")
+		}
+		for _, m := range mounts {
+			if m.v2 != (len(controllers) == 0) || !containsAll(m.controllers, controllers) {
+				continue
+			}
+			files = append(files, filepath.Join(m.mountPoint, relativeCGroupPath(m.root, parts[2]), cgroupProcs))
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("no mounted cgroup is found")
+	}
+	return files, nil
+}
+
+// readCGroupMounts reads the mounted cgroup hierarchies from the mountinfo, whose lines are like
+// "36 32 0:32 / /sys/fs/cgroup/memory rw,relatime - cgroup cgroup rw,memory".
+func readCGroupMounts(mountInfo string) ([]cgroupMount, error) {
+	f, err := os.Open(mountInfo)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	return parseCGroupMounts(f)
+}
+
+func parseCGroupMounts(r io.Reader) ([]cgroupMount, error) {
+	var mounts []cgroupMount
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), " - ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields, super := strings.Fields(parts[0]), strings.Fields(parts[1])
+		if len(fields) < 5 || len(super) < 3 {
+			continue
+		}
+
+		switch super[0] {
+		case "cgroup":
+			var controllers []string
+			for _, opt := range strings.Split(super[2], ",") {
+				if opt != "rw" && opt != "ro" {
+					controllers = append(controllers, opt)
+				}
+			}
+			mounts = append(mounts, cgroupMount{mountPoint: fields[4], root: fields[3], controllers: controllers})
+		case "cgroup2":
+			mounts = append(mounts, cgroupMount{mountPoint: fields[4], root: fields[3], v2: true})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return mounts, nil
+}
+
+// relativeCGroupPath returns the path relative to the root of the mounted hierarchy,
+// the root isn't "/" if the hierarchy is mounted in a cgroup namespace.
+func relativeCGroupPath(root string, path string) string {
+	if root != "/" && strings.HasPrefix(path, root) {
+		return strings.TrimPrefix(path, root)
+	}
+	return path
+}
+
+func containsAll(set []string, items []string) bool {
+	for _, item := range items {
+		found := false
+		for _, s := range set {
+			if s == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testMountInfo = `25 30 0:23 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw
+32 25 0:28 / /sys/fs/cgroup rw,relatime - tmpfs tmpfs rw,mode=755
+33 32 0:29 / /sys/fs/cgroup/systemd rw,nosuid,nodev,noexec,relatime - cgroup cgroup rw,xattr,name=systemd
+34 32 0:30 / /sys/fs/cgroup/cpu,cpuacct rw,nosuid,nodev,noexec,relatime - cgroup cgroup rw,cpu,cpuacct
+35 32 0:31 /kubepods /sys/fs/cgroup/memory rw,nosuid,nodev,noexec,relatime - cgroup cgroup rw,memory
+36 32 0:32 / /sys/fs/cgroup/unified rw,nosuid,nodev,noexec,relatime - cgroup2 cgroup2 rw,nsdelegate
+`
+
+func TestCGroupProcsOfPid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mounts, err := parseCGroupMounts(strings.NewReader(testMountInfo))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(mounts).Should(HaveLen(4))
+	g.Expect(mounts[1].controllers).Should(Equal([]string{"cpu", "cpuacct"}))
+	g.Expect(mounts[3].v2).Should(BeTrue())
+
+	files, err := cgroupProcsOfPid(mounts, strings.NewReader(`12:memory:/kubepods/pod1/abc
+4:cpu,cpuacct:/kubepods/pod1/abc
+2:blkio:/kubepods/pod1/abc
+1:name=systemd:/kubepods/pod1/abc
+0::/kubepods/pod1/abc
+`))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(files).Should(Equal([]string{
+		"/sys/fs/cgroup/memory/pod1/abc/cgroup.procs",
+		"/sys/fs/cgroup/cpu,cpuacct/kubepods/pod1/abc/cgroup.procs",
+		"/sys/fs/cgroup/systemd/kubepods/pod1/abc/cgroup.procs",
+		"/sys/fs/cgroup/unified/kubepods/pod1/abc/cgroup.procs",
+	}))
+
+	_, err = cgroupProcsOfPid(mounts, strings.NewReader("2:blkio:/kubepods/pod1/abc\n"))
+	g.Expect(err).Should(HaveOccurred())
+}
@@ -29,6 +29,24 @@ func GetDiskTotalSize(path string) (total uint64, err error) {
 	return total, err
 }
 
+// GetDiskAvailSize returns the bytes available to unprivileged users in disk
+func GetDiskAvailSize(path string) (uint64, error) {
+	s := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &s); err != nil {
+		return 0, err
+	}
+	return uint64(s.Bsize) * uint64(s.Bavail), nil
+}
+
+// GetDiskInodes returns the total and free inodes in disk
+func GetDiskInodes(path string) (total uint64, free uint64, err error) {
+	s := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &s); err != nil {
+		return 0, 0, err
+	}
+	return s.Files, s.Ffree, nil
+}
+
 func GetRootDevice() (string, error) {
 	// TODO: complete get device of root on darwin
 	return "", nil
@@ -31,6 +31,24 @@ func GetDiskTotalSize(path string) (total uint64, err error) {
 	return total, nil
 }
 
+// GetDiskAvailSize returns the bytes available to unprivileged users in disk
+func GetDiskAvailSize(path string) (uint64, error) {
+	s := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &s); err != nil {
+		return 0, err
+	}
+	return uint64(s.Frsize) * s.Bavail, nil
+}
+
+// GetDiskInodes returns the total and free inodes in disk
+func GetDiskInodes(path string) (total uint64, free uint64, err error) {
+	s := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &s); err != nil {
+		return 0, 0, err
+	}
+	return uint64(s.Files), uint64(s.Ffree), nil
+}
+
 // GetRootDevice returns the device which "/" mount on.
 func GetRootDevice() (string, error) {
 	mapStat, err := disk.Partitions(false)